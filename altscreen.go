@@ -0,0 +1,48 @@
+package terminal
+
+// enterAltScreen swaps the alternate screen in, keeping the primary screen content for later.
+// If clear is true the alternate screen starts empty, otherwise it shows what was left there last time.
+func (t *Terminal) enterAltScreen(clear bool) {
+	if t.altScreen {
+		if clear {
+			t.content.Rows, t.content.RowInfos = nil, nil
+		}
+		return
+	}
+	if t.hasSelectedText() {
+		t.clearSelectedText()
+	}
+	t.scrollToBottom()
+
+	t.primaryRows, t.primaryInfos = t.content.Rows, t.content.RowInfos
+	t.content.Rows, t.content.RowInfos = nil, nil
+	if !clear {
+		t.content.Rows, t.content.RowInfos = t.altRows, t.altInfos
+	}
+	t.altRows, t.altInfos = nil, nil
+	t.altScreen = true
+	t.hyperlink = nil
+	t.kittyStack, t.kittyAltStack = t.kittyAltStack, t.kittyStack
+	t.refreshContent()
+}
+
+// exitAltScreen swaps the primary screen back in.
+// If clear is true the alternate screen content is discarded, otherwise it is kept for the next time it is used.
+func (t *Terminal) exitAltScreen(clear bool) {
+	if !t.altScreen {
+		return
+	}
+	if t.hasSelectedText() {
+		t.clearSelectedText()
+	}
+
+	if !clear {
+		t.altRows, t.altInfos = t.content.Rows, t.content.RowInfos
+	}
+	t.content.Rows, t.content.RowInfos = t.primaryRows, t.primaryInfos
+	t.primaryRows, t.primaryInfos = nil, nil
+	t.altScreen = false
+	t.hyperlink = nil
+	t.kittyStack, t.kittyAltStack = t.kittyAltStack, t.kittyStack
+	t.refreshContent()
+}
@@ -0,0 +1,133 @@
+package terminal
+
+import (
+	"encoding/base64"
+	"log"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+)
+
+// defaultClipboardLimit is the largest text, in bytes, that applications can copy to the clipboard by default.
+const defaultClipboardLimit = 1 << 20
+
+// ClipboardPolicy controls how applications can use the clipboard through OSC 52.
+type ClipboardPolicy int
+
+const (
+	// ClipboardWriteOnly lets applications copy to the clipboard but not read it, this is the default.
+	ClipboardWriteOnly ClipboardPolicy = iota
+	// ClipboardDeny ignores all clipboard requests.
+	ClipboardDeny
+	// ClipboardReadWrite lets applications copy to the clipboard and read its contents.
+	ClipboardReadWrite
+	// ClipboardAsk asks the user each time an application wants to copy to or read the clipboard.
+	ClipboardAsk
+)
+
+// SetClipboardPolicy sets whether programs running in the terminal can set or read the clipboard.
+// Reading should only be allowed if the output is trusted, as anything with access to the output could read it.
+func (t *Terminal) SetClipboardPolicy(policy ClipboardPolicy) {
+	t.clipboardPolicy = policy
+}
+
+// SetClipboardLimit sets the largest text, in bytes, that programs running in the terminal can copy to the clipboard.
+func (t *Terminal) SetClipboardLimit(bytes int) {
+	t.clipboardLimit = bytes
+}
+
+// handleClipboard handles OSC 52, which has the format `selections;data`.
+// The data is either base64 encoded text to copy or `?` to ask for the clipboard content.
+// We have just the one clipboard so the selections are ignored.
+func (t *Terminal) handleClipboard(code string) {
+	selections, data, ok := strings.Cut(code, ";")
+	if !ok {
+		if t.debug {
+			log.Println("Invalid clipboard request", code)
+		}
+		return
+	}
+
+	if data == "?" {
+		t.allowClipboard(true, func() {
+			content := fyne.CurrentApp().Clipboard().Content()
+			t.reply("%c]52;%s;%s%c\\", asciiEscape, selections, base64.StdEncoding.EncodeToString([]byte(content)), asciiEscape)
+		})
+		return
+	}
+
+	if len(data) > base64.StdEncoding.EncodedLen(t.clipboardLimit) { // don't decode anything far too long
+		t.clipboardOverLimit()
+		return
+	}
+	text, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		if t.debug {
+			log.Println("Invalid clipboard content", err)
+		}
+		return
+	}
+	if len(text) > t.clipboardLimit {
+		t.clipboardOverLimit()
+		return
+	}
+	t.allowClipboard(false, func() {
+		fyne.CurrentApp().Clipboard().SetContent(string(text))
+	})
+}
+
+func (t *Terminal) clipboardOverLimit() {
+	if t.debug {
+		log.Println("Clipboard content over the limit of", t.clipboardLimit, "bytes")
+	}
+}
+
+// allowClipboard calls access if the clipboard policy allows it, which may mean asking the user first.
+func (t *Terminal) allowClipboard(read bool, access func()) {
+	switch t.clipboardPolicy {
+	case ClipboardReadWrite:
+		access()
+	case ClipboardWriteOnly:
+		if !read {
+			access()
+		}
+	case ClipboardAsk:
+		t.askClipboard(read, access)
+	}
+}
+
+// askClipboard shows a dialog asking if the application can use the clipboard, calling access if it can.
+// Requests made while the question is showing are refused, so that a program cannot flood the user with them.
+func (t *Terminal) askClipboard(read bool, access func()) {
+	win := t.window()
+	if win == nil || t.clipboardAsking {
+		return
+	}
+
+	message := "A program in the terminal wants to copy text to the clipboard."
+	if read {
+		message = "A program in the terminal wants to read the clipboard."
+	}
+	t.clipboardAsking = true
+	dialog.ShowConfirm("Clipboard access", message+"\nDo you want to allow it?", func(ok bool) {
+		t.clipboardAsking = false
+		if ok {
+			access()
+		}
+	}, win)
+}
+
+// window returns the window that the terminal is shown in, or nil if it is not in one.
+func (t *Terminal) window() fyne.Window {
+	c := fyne.CurrentApp().Driver().CanvasForObject(t)
+	if c == nil {
+		return nil
+	}
+	for _, w := range fyne.CurrentApp().Driver().AllWindows() {
+		if w.Canvas() == c {
+			return w
+		}
+	}
+	return nil
+}
@@ -84,6 +84,15 @@ func newTerminalWindow(a fyne.App, debug bool) fyne.Window {
 
 	t := terminal.New()
 	t.SetDebug(debug)
+	t.OnNotification = func(title, body string) {
+		if t.Focused() {
+			return // only notify if the user is looking at another window
+		}
+		if title == "" {
+			title = termTitle()
+		}
+		a.SendNotification(fyne.NewNotification(title, body))
+	}
 	setupListener(t, w)
 	sizeOverride := container.NewThemeOverride(container.NewStack(bg, img, over, t), th)
 	w.SetContent(sizeOverride)
@@ -7,7 +7,7 @@ import (
 	"strings"
 
 	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/theme"
+	widget2 "github.com/wangyiyang/Magic-Terminal/internal/widget"
 )
 
 var (
@@ -43,10 +43,7 @@ var (
 
 func (t *Terminal) handleColorEscape(message string) {
 	if message == "" || message == "0" {
-		t.currentBG = nil
-		t.currentFG = nil
-		t.bold = false
-		t.blinking = false
+		t.resetColorModes()
 		return
 	}
 	modes := strings.Split(message, ";")
@@ -56,7 +53,9 @@ func (t *Terminal) handleColorEscape(message string) {
 			continue
 		}
 
-		if (mode == "38" || mode == "48") && i+1 < len(modes) {
+		if strings.Contains(mode, ":") {
+			t.handleColorSubParams(strings.Split(mode, ":"))
+		} else if (mode == "38" || mode == "48" || mode == "58") && i+1 < len(modes) {
 			nextMode := modes[i+1]
 			if nextMode == "5" && i+2 < len(modes) {
 				t.handleColorModeMap(mode, modes[i+2])
@@ -79,50 +78,57 @@ func (t *Terminal) handleColorMode(modeStr string) {
 	}
 	switch mode {
 	case 0:
-		t.currentBG, t.currentFG = nil, nil
-		t.bold = false
-		t.blinking = false
+		t.resetColorModes()
 	case 1:
-		t.bold = true
-	case 4, 24: //italic
-	case 5:
+		t.attributes |= widget2.AttributeBold
+	case 2:
+		t.attributes |= widget2.AttributeDim
+	case 3:
+		t.attributes |= widget2.AttributeItalic
+	case 4:
+		t.setUnderline(widget2.UnderlineSingle)
+	case 21:
+		t.setUnderline(widget2.UnderlineDouble)
+	case 5, 6: // slow and rapid blink
 		t.blinking = true
-	case 7: // reverse
-		bg, fg := t.currentBG, t.currentFG
-		if fg == nil {
-			t.currentBG = theme.Color(theme.ColorNameForeground)
-		} else {
-			t.currentBG = fg
-		}
-		if bg == nil {
-			t.currentFG = theme.Color(theme.ColorNameDisabledButton)
-		} else {
-			t.currentFG = bg
-		}
-	case 27: // reverse off
-		bg, fg := t.currentBG, t.currentFG
-		if fg != nil {
-			t.currentBG = nil
-		} else {
-			t.currentBG = fg
-		}
-		if bg != nil {
-			t.currentFG = nil
-		} else {
-			t.currentFG = bg
-		}
+	case 7:
+		t.attributes |= widget2.AttributeInverse
+	case 8:
+		t.attributes |= widget2.AttributeHidden
+	case 9:
+		t.attributes |= widget2.AttributeStrikethrough
+	case 22: // normal intensity
+		t.attributes &^= widget2.AttributeBold | widget2.AttributeDim
+	case 23:
+		t.attributes &^= widget2.AttributeItalic
+	case 24:
+		t.attributes &^= widget2.AttributeUnderline
+	case 25:
+		t.blinking = false
+	case 27:
+		t.attributes &^= widget2.AttributeInverse
+	case 28:
+		t.attributes &^= widget2.AttributeHidden
+	case 29:
+		t.attributes &^= widget2.AttributeStrikethrough
+	case 53:
+		t.attributes |= widget2.AttributeOverline
+	case 55:
+		t.attributes &^= widget2.AttributeOverline
+	case 59:
+		t.underlineColor = nil
 	case 30, 31, 32, 33, 34, 35, 36, 37:
-		t.currentFG = basicColors[mode-30]
+		t.currentFG = t.paletteColor(mode - 30)
 	case 39:
 		t.currentFG = nil
 	case 40, 41, 42, 43, 44, 45, 46, 47:
-		t.currentBG = basicColors[mode-40]
+		t.currentBG = t.paletteColor(mode - 40)
 	case 49:
 		t.currentBG = nil
 	case 90, 91, 92, 93, 94, 95, 96, 97:
-		t.currentFG = brightColors[mode-90]
+		t.currentFG = t.paletteColor(mode - 90 + 8)
 	case 100, 101, 102, 103, 104, 105, 106, 107:
-		t.currentBG = brightColors[mode-100]
+		t.currentBG = t.paletteColor(mode - 100 + 8)
 	default:
 		if t.debug {
 			log.Println("Unsupported graphics mode", mode)
@@ -130,6 +136,37 @@ func (t *Terminal) handleColorMode(modeStr string) {
 	}
 }
 
+// handleColorSubParams handles a mode with colon separated sub-parameters,
+// such as `4:3` for a curly underline or `38:2::r:g:b` for an RGB colour.
+func (t *Terminal) handleColorSubParams(params []string) {
+	switch params[0] {
+	case "4":
+		style, _ := strconv.Atoi(params[1])
+		switch style {
+		case 0:
+			t.attributes &^= widget2.AttributeUnderline
+		case 1, 2, 3, 4, 5:
+			t.setUnderline(widget2.UnderlineStyle(style - 1))
+		default:
+			if t.debug {
+				log.Println("Unsupported underline style", style)
+			}
+		}
+	case "38", "48", "58":
+		if params[1] == "5" && len(params) > 2 {
+			t.handleColorModeMap(params[0], params[2])
+		} else if params[1] == "2" && len(params) > 5 { // colour space ID is included, possibly empty
+			t.handleColorModeRGB(params[0], params[3], params[4], params[5])
+		} else if params[1] == "2" && len(params) == 5 {
+			t.handleColorModeRGB(params[0], params[2], params[3], params[4])
+		} else if t.debug {
+			log.Println("Invalid colour parameters", strings.Join(params, ":"))
+		}
+	default:
+		t.handleColorMode(params[0])
+	}
+}
+
 func (t *Terminal) handleColorModeMap(mode, ids string) {
 	var c color.Color
 	id, err := strconv.Atoi(ids)
@@ -139,31 +176,13 @@ func (t *Terminal) handleColorModeMap(mode, ids string) {
 		}
 		return
 	}
-	if id <= 7 {
-		c = basicColors[id]
-	} else if id <= 15 {
-		c = brightColors[id-8]
-	} else if id <= 231 {
-		id -= 16
-		b := id % 6
-		id = (id - b) / 6
-		g := id % 6
-		r := (id - g) / 6
-		c = &color.RGBA{colourBands[r], colourBands[g], colourBands[b], 255}
-	} else if id <= 255 {
-		id -= 232
-		inc := 256 / 24
-		y := id * inc
-		c = &color.Gray{uint8(y)}
+	if id >= 0 && id <= 255 {
+		c = t.paletteColor(id)
 	} else if t.debug {
 		log.Println("Invalid colour map ID", id)
 	}
 
-	if mode == "38" {
-		t.currentFG = c
-	} else if mode == "48" {
-		t.currentBG = c
-	}
+	t.setModeColor(mode, c)
 }
 
 func (t *Terminal) handleColorModeRGB(mode, rs, gs, bs string) {
@@ -172,9 +191,43 @@ func (t *Terminal) handleColorModeRGB(mode, rs, gs, bs string) {
 	b, _ := strconv.Atoi(bs)
 	c := &color.RGBA{uint8(r), uint8(g), uint8(b), 255}
 
-	if mode == "38" {
+	t.setModeColor(mode, c)
+}
+
+func (t *Terminal) resetColorModes() {
+	t.currentBG, t.currentFG = nil, nil
+	t.attributes = 0
+	t.underlineStyle, t.underlineColor = widget2.UnderlineSingle, nil
+	t.blinking = false
+}
+
+func (t *Terminal) setModeColor(mode string, c color.Color) {
+	switch mode {
+	case "38":
 		t.currentFG = c
-	} else if mode == "48" {
+	case "48":
 		t.currentBG = c
+	case "58":
+		t.underlineColor = c
 	}
 }
+
+func (t *Terminal) setUnderline(style widget2.UnderlineStyle) {
+	t.attributes |= widget2.AttributeUnderline
+	t.underlineStyle = style
+}
+
+// setScreenReversed turns reverse video for the whole screen (DECSCNM) on or off.
+// As in xterm and VTE the default text and background colours are swapped when drawing,
+// text in an explicit colour keeps it and the cells themselves are not changed.
+func (t *Terminal) setScreenReversed(reversed bool) {
+	if t.screenReversed == reversed {
+		return
+	}
+	t.screenReversed = reversed
+	if t.content == nil {
+		return
+	}
+
+	t.Refresh()
+}
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"github.com/stretchr/testify/assert"
 	widget2 "github.com/wangyiyang/Magic-Terminal/internal/widget"
@@ -36,8 +37,8 @@ func testColor(t *testing.T, tests map[string]struct {
 			if !reflect.DeepEqual(terminal.currentBG, test.expectedBg) {
 				t.Errorf("Background color mismatch. Got %v, expected %v", terminal.currentBG, test.expectedBg)
 			}
-			if terminal.bold != test.expectedBold {
-				t.Errorf("Bold flag mismatch. Got %v, expected %v", terminal.bold, test.expectedBold)
+			if bold := terminal.attributes&widget2.AttributeBold != 0; bold != test.expectedBold {
+				t.Errorf("Bold flag mismatch. Got %v, expected %v", bold, test.expectedBold)
 			}
 		})
 	}
@@ -60,13 +61,56 @@ func TestHandleOutput_Text(t *testing.T) {
 			terminal := New()
 			terminal.handleOutput([]byte(test.inputSeq))
 
-			if terminal.bold != test.expectBold {
-				t.Errorf("Bold flag mismatch. Got %v, expected %v", terminal.bold, test.expectBold)
+			if bold := terminal.attributes&widget2.AttributeBold != 0; bold != test.expectBold {
+				t.Errorf("Bold flag mismatch. Got %v, expected %v", bold, test.expectBold)
 			}
 		})
 	}
 }
 
+func TestHandleOutput_Attributes(t *testing.T) {
+	tests := map[string]struct {
+		inputSeq string
+		expected widget2.TextAttribute
+	}{
+		"dim":              {esc("[2m"), widget2.AttributeDim},
+		"italic":           {esc("[3m"), widget2.AttributeItalic},
+		"underline":        {esc("[4m"), widget2.AttributeUnderline},
+		"hidden":           {esc("[8m"), widget2.AttributeHidden},
+		"strikethrough":    {esc("[9m"), widget2.AttributeStrikethrough},
+		"overline":         {esc("[53m"), widget2.AttributeOverline},
+		"combined":         {esc("[1;3;9m"), widget2.AttributeBold | widget2.AttributeItalic | widget2.AttributeStrikethrough},
+		"normal intensity": {esc("[1;2;3m") + esc("[22m"), widget2.AttributeItalic},
+		"resets":           {esc("[3;4;8;9;53m") + esc("[23;24;28;29;55m"), 0},
+		"reset all":        {esc("[1;2;3;4;8;9;53m") + esc("[0m"), 0},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			terminal := New()
+			terminal.handleOutput([]byte(test.inputSeq))
+
+			assert.Equal(t, test.expected, terminal.attributes)
+		})
+	}
+}
+
+func TestHandleOutput_AttributeStyle(t *testing.T) {
+	term := New()
+	term.config.Columns = 5
+	term.config.Rows = 1
+	term.Refresh() // ensure visuals set up
+
+	term.handleOutput([]byte(esc("[1;3;4mA") + esc("[mB")))
+	s, ok := term.content.Rows[0].Cells[0].Style.(*widget2.TermTextGridStyle)
+	assert.True(t, ok)
+	assert.Equal(t, widget2.AttributeBold|widget2.AttributeItalic|widget2.AttributeUnderline, s.Attributes)
+	assert.Equal(t, fyne.TextStyle{Bold: true, Italic: true}, s.Style())
+
+	_, ok = term.content.Rows[0].Cells[1].Style.(*widget.CustomTextGridStyle)
+	assert.True(t, ok)
+}
+
 func TestHandleOutput_Normal_Text(t *testing.T) {
 	tests := map[string]struct {
 		inputSeq     string
@@ -76,14 +120,14 @@ func TestHandleOutput_Normal_Text(t *testing.T) {
 	}{
 		"reverse video": {
 			inputSeq:     esc("[7m"),
-			expectedFg:   color.NRGBA{R: 34, G: 34, B: 34, A: 255},
-			expectedBg:   color.NRGBA{R: 255, G: 255, B: 255, A: 255},
+			expectedFg:   nil,
+			expectedBg:   nil,
 			expectedBold: false,
 		},
 		"reverse video and bold": {
 			inputSeq:     esc("[7m") + esc("[1m"),
-			expectedFg:   color.NRGBA{R: 34, G: 34, B: 34, A: 255},
-			expectedBg:   color.NRGBA{R: 255, G: 255, B: 255, A: 255},
+			expectedFg:   nil,
+			expectedBg:   nil,
 			expectedBold: true,
 		},
 		"reverse video and bold then reset": {
@@ -97,6 +141,71 @@ func TestHandleOutput_Normal_Text(t *testing.T) {
 	testColor(t, tests)
 }
 
+func TestHandleOutput_Inverse(t *testing.T) {
+	term := New()
+	term.config.Columns = 5
+	term.config.Rows = 1
+	term.Refresh() // ensure visuals set up
+
+	term.handleOutput([]byte(esc("[7;31mA") + esc("[31;7mB") + esc("[27mC") + esc("[m") + esc("[7mD")))
+	cells := term.content.Rows[0].Cells
+	red := basicColors[1]
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, theme.Color(theme.ColorNameBackground), cells[i].Style.TextColor())
+		assert.Equal(t, red, cells[i].Style.BackgroundColor())
+	}
+	assert.Equal(t, red, cells[2].Style.TextColor())
+	assert.Nil(t, cells[2].Style.BackgroundColor())
+	assert.Equal(t, theme.Color(theme.ColorNameBackground), cells[3].Style.TextColor())
+	assert.Equal(t, theme.Color(theme.ColorNameForeground), cells[3].Style.BackgroundColor())
+}
+
+func TestHandleOutput_ScreenReverse(t *testing.T) {
+	term := New()
+	term.config.Columns = 5
+	term.config.Rows = 1
+	term.Refresh() // ensure visuals set up
+	colors := &colorTheme{term: term}
+	v := theme.VariantDark
+	fg, bg := term.Theme().Color(theme.ColorNameForeground, v), term.Theme().Color(theme.ColorNameBackground, v)
+
+	term.handleOutput([]byte("A" + esc("[7mB") + esc("[?5h") + esc("[m") + esc("[31mC")))
+	cells := term.content.Rows[0].Cells
+	assert.Nil(t, cells[0].Style.TextColor()) // the cells keep their colours and the theme swaps the defaults
+	assert.Nil(t, cells[0].Style.BackgroundColor())
+	assert.Equal(t, bg, colors.Color(theme.ColorNameForeground, v))
+	assert.Equal(t, fg, colors.Color(theme.ColorNameBackground, v))
+	assert.Equal(t, theme.Color(theme.ColorNameForeground), cells[1].Style.TextColor()) // inverse shows normally
+	assert.Equal(t, theme.Color(theme.ColorNameBackground), cells[1].Style.BackgroundColor())
+	assert.Equal(t, basicColors[1], cells[2].Style.TextColor())
+	assert.Nil(t, cells[2].Style.BackgroundColor())
+
+	term.handleOutput([]byte(esc("[?5l")))
+	assert.Equal(t, fg, colors.Color(theme.ColorNameForeground, v))
+	assert.Equal(t, theme.Color(theme.ColorNameBackground), cells[1].Style.TextColor())
+	assert.Equal(t, theme.Color(theme.ColorNameForeground), cells[1].Style.BackgroundColor())
+	assert.Equal(t, basicColors[1], cells[2].Style.TextColor())
+}
+
+func TestHandleOutput_InverseDynamicColors(t *testing.T) {
+	term := New()
+	term.config.Columns = 5
+	term.config.Rows = 1
+	term.Refresh() // ensure visuals set up
+
+	term.handleOutput([]byte("\x1b]10;rgb:ff/00/00\x07\x1b]11;rgb:00/00/ff\x07" + esc("[7mA")))
+	term.Refresh() // the grid passes its theme to the cells as it draws
+	red, blue := term.defaultFG, term.defaultBG
+	assert.NotNil(t, red)
+	cell := term.content.Rows[0].Cells[0]
+	assert.Equal(t, blue, cell.Style.TextColor())
+	assert.Equal(t, red, cell.Style.BackgroundColor())
+
+	term.handleOutput([]byte(esc("[?5h"))) // the screen reverse swaps them back
+	assert.Equal(t, red, cell.Style.TextColor())
+	assert.Equal(t, blue, cell.Style.BackgroundColor())
+}
+
 func TestHandleOutput_ANSI_Colors(t *testing.T) {
 	tests := map[string]struct {
 		inputSeq     string
@@ -161,7 +270,7 @@ func TestHandleOutput_ANSI_Colors(t *testing.T) {
 		"reverse video": {
 			inputSeq:     esc("[7m") + esc("[37m"),
 			expectedFg:   &color.RGBA{170, 170, 170, 255},
-			expectedBg:   color.NRGBA{255, 255, 255, 255},
+			expectedBg:   nil,
 			expectedBold: false,
 		},
 	}
@@ -930,3 +1039,63 @@ func TestHandleOutput_BufferCutoff(t *testing.T) {
 	}
 	assert.Equal(t, tg.Rows, term.content.Rows)
 }
+
+func TestHandleOutput_Underline(t *testing.T) {
+	tests := map[string]struct {
+		inputSeq  string
+		underline bool
+		style     widget2.UnderlineStyle
+		color     color.Color
+	}{
+		"single":           {esc("[4m"), true, widget2.UnderlineSingle, nil},
+		"double":           {esc("[21m"), true, widget2.UnderlineDouble, nil},
+		"sub single":       {esc("[4:1m"), true, widget2.UnderlineSingle, nil},
+		"sub double":       {esc("[4:2m"), true, widget2.UnderlineDouble, nil},
+		"curly":            {esc("[4:3m"), true, widget2.UnderlineCurly, nil},
+		"dotted":           {esc("[4:4m"), true, widget2.UnderlineDotted, nil},
+		"dashed":           {esc("[4:5m"), true, widget2.UnderlineDashed, nil},
+		"sub off":          {esc("[4:3m") + esc("[4:0m"), false, widget2.UnderlineCurly, nil},
+		"colour":           {esc("[4;58;2;255;0;0m"), true, widget2.UnderlineSingle, &color.RGBA{255, 0, 0, 255}},
+		"colour map":       {esc("[58;5;1m"), false, widget2.UnderlineSingle, basicColors[1]},
+		"sub colour":       {esc("[4:3;58:2::0:255:0m"), true, widget2.UnderlineCurly, &color.RGBA{0, 255, 0, 255}},
+		"sub colour no id": {esc("[58:2:0:0:255m"), false, widget2.UnderlineSingle, &color.RGBA{0, 0, 255, 255}},
+		"colour reset":     {esc("[58;5;1m") + esc("[59m"), false, widget2.UnderlineSingle, nil},
+		"reset all":        {esc("[4:3;58;5;1m") + esc("[m"), false, widget2.UnderlineSingle, nil},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			terminal := New()
+			terminal.handleOutput([]byte(test.inputSeq))
+
+			assert.Equal(t, test.underline, terminal.attributes&widget2.AttributeUnderline != 0)
+			assert.Equal(t, test.style, terminal.underlineStyle)
+			assert.Equal(t, test.color, terminal.underlineColor)
+		})
+	}
+}
+
+func TestHandleOutput_SubParamColour(t *testing.T) {
+	tests := map[string]struct {
+		inputSeq     string
+		expectedFg   color.Color
+		expectedBg   color.Color
+		expectedBold bool
+	}{
+		"RGB with colour space": {
+			inputSeq:   esc("[38:2::112:128:144m"),
+			expectedFg: &color.RGBA{112, 128, 144, 255},
+		},
+		"RGB without colour space": {
+			inputSeq:   esc("[48:2:107:142:35m"),
+			expectedBg: &color.RGBA{107, 142, 35, 255},
+		},
+		"256 colour": {
+			inputSeq:     esc("[1;38:5:1m"),
+			expectedFg:   basicColors[1],
+			expectedBold: true,
+		},
+	}
+
+	testColor(t, tests)
+}
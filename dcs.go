@@ -0,0 +1,42 @@
+package terminal
+
+import (
+	"log"
+	"strings"
+)
+
+// DCSHandler handles a DCS command for the given terminal.
+type DCSHandler func(*Terminal, string)
+
+var dcsHandlers = map[string]func(*Terminal, string){
+	"$q": func(t *Terminal, setting string) {
+		t.requestStatusString(setting)
+	},
+	"+q": func(t *Terminal, names string) {
+		t.requestCapabilities(names)
+	},
+}
+
+func (t *Terminal) handleDCS(code string) {
+	command := ""
+	for dcsCommand := range dcsHandlers {
+		// prefer the longest match, so handlers for a specific command win over more general ones
+		if strings.HasPrefix(code, dcsCommand) && len(dcsCommand) > len(command) {
+			command = dcsCommand
+		}
+	}
+	if handler, ok := dcsHandlers[command]; ok {
+		handler(t, code[len(command):])
+		return
+	}
+
+	if t.debug {
+		log.Println("Unrecognised DCS", code)
+	}
+}
+
+// RegisterDCSHandler registers a DCS handler for the given DCS command string.
+// The handler is passed the remainder of the string, up to the string terminator.
+func RegisterDCSHandler(DCS string, handler DCSHandler) {
+	dcsHandlers[DCS] = handler
+}
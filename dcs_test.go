@@ -0,0 +1,67 @@
+package terminal
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDCS(t *testing.T) {
+	var DCSString string
+
+	RegisterDCSHandler("+test", func(terminal *Terminal, s string) {
+		DCSString = s
+	})
+
+	testCases := map[string]struct {
+		input    []string
+		expected string
+		content  string
+	}{
+		"terminated by ST": {
+			input:    []string{"\x1bP+testHello\x1b\\after"},
+			expected: "Hello",
+			content:  "after",
+		},
+		"split across reads": {
+			input:    []string{"\x1bP+testHel", "lo\x1b", "\\after"},
+			expected: "Hello",
+			content:  "after",
+		},
+		"cancelled by CAN": {
+			input:    []string{"\x1bP+testHello\x18after\x1b\\"},
+			expected: "",
+			content:  "after",
+		},
+		"cancelled by SUB": {
+			input:    []string{"\x1bP+testHello\x1aafter"},
+			expected: "",
+			content:  "after",
+		},
+		"aborted by escape": {
+			input:    []string{"\x1bP+testHello\x1b[1Cafter"},
+			expected: "",
+			content:  " after",
+		},
+		"unregistered": {
+			input:    []string{"\x1bPunknown\x1b\\after"},
+			expected: "",
+			content:  "after",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			DCSString = ""
+			term := New()
+			term.Resize(fyne.NewSize(150, 50))
+			for _, in := range testCase.input {
+				term.handleOutput([]byte(in))
+			}
+
+			assert.Equal(t, testCase.expected, DCSString)
+			assert.Equal(t, testCase.content, term.content.Text())
+		})
+	}
+}
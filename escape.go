@@ -7,12 +7,14 @@ import (
 	"strings"
 
 	"fyne.io/fyne/v2/widget"
+	widget2 "github.com/wangyiyang/Magic-Terminal/internal/widget"
 )
 
 var escapes = map[rune]func(*Terminal, string){
 	'@': escapeInsertChars,
 	'A': escapeMoveCursorUp,
 	'B': escapeMoveCursorDown,
+	'c': escapeDeviceAttributes,
 	'C': escapeMoveCursorRight,
 	'D': escapeMoveCursorLeft,
 	'd': escapeMoveCursorRow,
@@ -23,13 +25,16 @@ var escapes = map[rune]func(*Terminal, string){
 	'L': escapeInsertLines,
 	'l': escapePrivateModeOff,
 	'm': escapeColorMode,
+	'n': escapeDeviceStatusReport,
 	'J': escapeEraseInScreen,
 	'K': escapeEraseInLine,
 	'P': escapeDeleteChars,
+	'p': escapeRequestMode,
+	'q': escapeQ,
 	'r': escapeSetScrollArea,
 	's': escapeSaveCursor,
 	'S': escapeScrollUp,
-	'u': escapeRestoreCursor,
+	'u': escapeKeyboardProtocol,
 	'i': escapePrinterMode,
 }
 
@@ -50,6 +55,7 @@ func (t *Terminal) handleEscape(code string) {
 func (t *Terminal) clearScreen() {
 	t.moveCursor(0, 0)
 	t.clearScreenFromCursor()
+	t.content.RowInfos = nil // including the prompt marks of the top row
 }
 
 func (t *Terminal) clearScreenFromCursor() {
@@ -63,10 +69,14 @@ func (t *Terminal) clearScreenFromCursor() {
 	} else {
 		t.content.SetRow(t.cursorRow, widget.TextGridRow{})
 	}
+	t.setRowWrapped(t.cursorRow, false)
 
 	for i := t.cursorRow + 1; i < len(t.content.Rows); i++ {
 		t.content.SetRow(i, widget.TextGridRow{})
 	}
+	if len(t.content.RowInfos) > t.cursorRow+1 {
+		t.content.RowInfos = t.content.RowInfos[:t.cursorRow+1]
+	}
 }
 
 func (t *Terminal) clearScreenToCursor() {
@@ -76,9 +86,11 @@ func (t *Terminal) clearScreenToCursor() {
 		cells = append(cells, row.Cells[t.cursorCol:]...)
 	}
 	t.content.SetRow(t.cursorRow, widget.TextGridRow{Cells: cells})
+	t.setRowWrapped(t.cursorRow, false)
 
 	for i := 0; i < t.cursorRow-1; i++ {
 		t.content.SetRow(i, widget.TextGridRow{})
+		t.content.SetRowInfo(i, widget2.RowInfo{})
 	}
 }
 
@@ -122,12 +134,16 @@ func (t *Terminal) moveCursor(row, col int) {
 	t.cursorCol = col
 	t.cursorRow = row
 
-	if t.cursorMoved != nil {
+	if t.cursorMoved != nil && !t.syncUpdate { // the cursor is placed by the refresh that ends the update
 		t.cursorMoved()
 	}
 }
 
 func escapeColorMode(t *Terminal, msg string) {
+	if strings.HasPrefix(msg, ">") {
+		t.handleModifyKeys(msg[1:])
+		return
+	}
 	t.handleColorEscape(msg)
 }
 
@@ -149,6 +165,7 @@ func escapeDeleteChars(t *Terminal, msg string) {
 
 func escapeEraseInLine(t *Terminal, msg string) {
 	mode, _ := strconv.Atoi(msg)
+	t.setRowWrapped(t.cursorRow, false)
 	switch mode {
 	case 0:
 		row := t.content.Row(t.cursorRow)
@@ -182,6 +199,9 @@ func escapeEraseInScreen(t *Terminal, msg string) {
 		t.clearScreenToCursor()
 	case 2:
 		t.clearScreen()
+	case 3:
+		t.scrollback.clear()
+		t.scrollToBottom()
 	}
 }
 
@@ -200,6 +220,9 @@ func escapeInsertChars(t *Terminal, msg string) {
 		}
 	}
 
+	if t.cursorRow >= len(t.content.Rows) || t.cursorCol >= len(t.content.Rows[t.cursorRow].Cells) {
+		return // nothing to move along, the inserted blanks look like the empty cells
+	}
 	row := &t.content.Rows[t.cursorRow]
 	row.Cells = append(row.Cells[:t.cursorCol], append(newCells, row.Cells[t.cursorCol:]...)...)
 }
@@ -212,9 +235,11 @@ func escapeInsertLines(t *Terminal, msg string) {
 	i := t.scrollBottom
 	for ; i > t.cursorRow-rows; i-- {
 		t.content.SetRow(i, t.content.Row(i-rows))
+		t.content.SetRowInfo(i, t.content.RowInfo(i-rows))
 	}
 	for ; i >= t.cursorRow; i-- {
 		t.content.SetRow(i, widget.TextGridRow{})
+		t.content.SetRowInfo(i, widget2.RowInfo{})
 	}
 }
 
@@ -263,51 +288,18 @@ func escapeMoveCursorCol(t *Terminal, msg string) {
 func escapePrivateMode(t *Terminal, msg string, enable bool) {
 	modes := strings.Split(msg, ";")
 	for _, mode := range modes {
-		switch mode {
-		case "7":
-			//TODO wrap around mode
-		case "20":
-			t.newLineMode = enable
-		case "25":
-			t.cursorHidden = !enable
-			t.refreshCursor()
-		case "9":
-			if enable {
-				t.onMouseDown = t.handleMouseDownX10
-				t.onMouseUp = t.handleMouseUpX10
-			} else {
-				t.onMouseDown = nil
-				t.onMouseUp = nil
-			}
-		case "1000":
-			if enable {
-				t.onMouseDown = t.handleMouseDownV200
-				t.onMouseUp = t.handleMouseUpV200
-			} else {
-				t.onMouseDown = nil
-				t.onMouseUp = nil
-			}
-		case "1049":
-			t.bufferMode = enable
-		case "2004":
-			t.bracketedPasteMode = enable
-		case "47":
-			// TODO save screen
-			/*
-				if enable {
-					// save screen
-				} else {
-					// restore screen
-				}
-			*/
-		default:
-			m := "l"
-			if enable {
-				m = "h"
-			}
-			if t.debug {
-				log.Println("Unknown private escape code", fmt.Sprintf("%s%s", mode, m))
-			}
+		num, err := strconv.Atoi(mode)
+		if m, ok := privateModes[num]; ok && err == nil {
+			m.set(t, enable)
+			continue
+		}
+
+		m := "l"
+		if enable {
+			m = "h"
+		}
+		if t.debug {
+			log.Println("Unknown private escape code", fmt.Sprintf("%s%s", mode, m))
 		}
 	}
 }
@@ -345,6 +337,38 @@ func escapeSaveCursor(t *Terminal, _ string) {
 	t.savedCol = t.cursorCol
 }
 
+// escapeQ handles the sequences ending in q, which are told apart by their prefix and intermediate character.
+func escapeQ(t *Terminal, msg string) {
+	switch {
+	case strings.HasSuffix(msg, " "):
+		escapeSetCursorShape(t, strings.TrimSuffix(msg, " "))
+	case strings.HasSuffix(msg, "\""):
+		// DECSCA, we do not support protected characters
+	default:
+		escapeReportVersion(t, msg)
+	}
+}
+
+// escapeSetCursorShape selects a block, underline or bar cursor (DECSCUSR).
+// Blinking is not supported so the blinking and steady styles look the same.
+func escapeSetCursorShape(t *Terminal, msg string) {
+	shape, _ := strconv.Atoi(msg)
+	if shape < 0 || shape > 6 {
+		if t.debug {
+			log.Println("Unsupported cursor shape", shape)
+		}
+		return
+	}
+
+	t.cursorShape = shape
+	if t.cursor != nil {
+		t.refreshCursor()
+		if t.cursorMoved != nil {
+			t.cursorMoved()
+		}
+	}
+}
+
 func escapeSetScrollArea(t *Terminal, msg string) {
 	parts := strings.Split(msg, ";")
 	start := 0
@@ -389,9 +413,11 @@ func escapeScrollUp(t *Terminal, msg string) {
 	// Perform the actual scrolling action
 	for i := t.scrollTop; i <= t.scrollBottom-lines; i++ {
 		t.content.SetRow(i, t.content.Row(i+lines))
+		t.content.SetRowInfo(i, t.content.RowInfo(i+lines))
 	}
 	for i := t.scrollBottom - lines + 1; i <= t.scrollBottom; i++ {
 		t.content.SetRow(i, widget.TextGridRow{}) // Clear the last lines
+		t.content.SetRowInfo(i, widget2.RowInfo{})
 	}
 }
 
@@ -402,7 +428,7 @@ func trimLeftZeros(s string) string {
 
 	i := 0
 	for _, r := range s {
-		if r > '0' {
+		if r != '0' && r >= ' ' { // keep intermediate characters such as the space in CSI 0 SP q
 			break
 		}
 		i++
@@ -207,6 +207,7 @@ func TestCursorMove_Overflow(t *testing.T) {
 
 func TestTrimLeftZeros(t *testing.T) {
 	assert.Equal(t, "1", trimLeftZeros(string([]byte{0, 0, '1'})))
+	assert.Equal(t, " q", trimLeftZeros("0 q"))
 }
 
 func TestHandleOutput_NewLineMode(t *testing.T) {
@@ -348,3 +349,105 @@ func TestTerminalEscapeSequences(t *testing.T) {
 		})
 	}
 }
+
+func TestAlternateScreen(t *testing.T) {
+	tests := map[string]struct {
+		mode                  string
+		keepsAlt, savesCursor bool
+	}{
+		"47":   {"47", true, false},
+		"1047": {"1047", false, false},
+		"1049": {"1049", false, true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			term := New()
+			term.config.Columns = 10
+			term.config.Rows = 3
+			term.scrollBottom = 2
+			term.Refresh() // ensure visuals set up
+
+			term.handleOutput([]byte("Hello"))
+			term.handleOutput([]byte("\x1b[?" + tt.mode + "h"))
+			assert.True(t, term.altScreen)
+			assert.Equal(t, "", term.content.Text())
+
+			term.handleOutput([]byte("\x1b[2;1HVim\r\n\r\n"))
+			assert.Equal(t, 0, term.scrollback.len())
+
+			term.handleOutput([]byte("\x1b[?" + tt.mode + "l"))
+			assert.False(t, term.altScreen)
+			assert.Equal(t, "Hello", term.content.Text())
+			if tt.savesCursor {
+				assert.Equal(t, 0, term.cursorRow)
+				assert.Equal(t, 5, term.cursorCol)
+			} else {
+				assert.Equal(t, 2, term.cursorRow)
+			}
+
+			term.handleOutput([]byte("\x1b[?" + tt.mode + "h"))
+			if tt.keepsAlt {
+				assert.Equal(t, "Vim", strings.TrimRight(term.content.Text(), "\n"))
+			} else {
+				assert.Equal(t, "", term.content.Text())
+			}
+			term.handleOutput([]byte("\x1b[?" + tt.mode + "l"))
+		})
+	}
+}
+
+func TestAlternateScreen_ReverseIndex(t *testing.T) {
+	for _, mode := range []string{"47", "1047", "1049"} {
+		t.Run(mode, func(t *testing.T) {
+			term := New()
+			term.config.Columns = 10
+			term.config.Rows = 3
+			term.scrollBottom = 2
+			term.Refresh() // ensure visuals set up
+
+			term.handleOutput([]byte("a\r\nb\r\nc\x1b[?" + mode + "h\x1b[H\x1bMtop\x1b[@"))
+			assert.Equal(t, "top", strings.TrimRight(term.content.Text(), "\n"))
+
+			term.handleOutput([]byte("\x1b[2;3r\x1b[2;1H\x1bMmid"))
+			assert.Equal(t, "top\nmid", strings.TrimRight(term.content.Text(), "\n"))
+		})
+	}
+}
+
+func TestApplicationCursorKeys(t *testing.T) {
+	term := New()
+	term.config.Columns = 5
+	term.config.Rows = 2
+	term.Refresh() // ensure visuals set up
+
+	term.handleOutput([]byte(esc("[?1049h")))
+	assert.False(t, term.appCursorKeys)
+
+	term.handleOutput([]byte(esc("[?1h")))
+	assert.True(t, term.appCursorKeys)
+	term.handleOutput([]byte(esc("[?1049l")))
+	assert.True(t, term.appCursorKeys)
+	term.handleOutput([]byte(esc("[?1l")))
+	assert.False(t, term.appCursorKeys)
+}
+
+func TestCursorShape(t *testing.T) {
+	term := New()
+	term.config.Columns = 5
+	term.config.Rows = 2
+	term.Refresh() // ensure visuals set up
+
+	term.handleOutput([]byte(esc("[2 q") + "a"))
+	assert.Equal(t, 2, term.cursorShape)
+	assert.Equal(t, "a", term.content.Text())
+	assert.Equal(t, term.guessCellSize(), term.cursor.Size())
+
+	term.handleOutput([]byte(esc("[4 q")))
+	assert.Equal(t, 4, term.cursorShape)
+	assert.Equal(t, float32(cursorWidth), term.cursor.Size().Height)
+
+	term.handleOutput([]byte(esc("[0 q")))
+	assert.Equal(t, 0, term.cursorShape)
+	assert.Equal(t, float32(cursorWidth), term.cursor.Size().Width)
+}
@@ -10,6 +10,11 @@ import (
 
 // TypedRune is called when the user types a visible character
 func (t *Terminal) TypedRune(r rune) {
+	if t.keyboardState.runeSent {
+		t.keyboardState.runeSent = false
+		return // already sent as an escape sequence by the key event
+	}
+	t.scrollToBottom()
 	b := make([]byte, utf8.UTFMax)
 	size := utf8.EncodeRune(b, r)
 	_, _ = t.in.Write(b[:size])
@@ -17,114 +22,38 @@ func (t *Terminal) TypedRune(r rune) {
 
 // TypedKey will be called if a non-printable keyboard event occurs
 func (t *Terminal) TypedKey(e *fyne.KeyEvent) {
-	if t.keyboardState.shiftPressed {
-		t.keyTypedWithShift(e)
+	if t.appKeypad && t.typeKeypadKey(e) {
+		t.scrollToBottom()
 		return
 	}
 
-	switch e.Name {
-	case fyne.KeyReturn:
-		_, _ = t.in.Write([]byte{'\r'})
-	case fyne.KeyEnter:
-		if t.newLineMode {
-			_, _ = t.in.Write([]byte{'\r'})
-			return
+	mods := t.keyboardModifiers()
+	if isModifierKey(e.Name) {
+		if t.kittyFlags()&kittyReportAllKeys != 0 {
+			t.typeKittyKey(e.Name, mods)
+		}
+		return
+	}
+	if mods == fyne.KeyModifierShift {
+		switch e.Name {
+		case fyne.KeyPageUp:
+			if t.scrollback.len() > 0 && !t.altScreen {
+				t.scrollHistory(int(t.config.Rows))
+				return
+			}
+		case fyne.KeyPageDown:
+			if t.scrollOffset > 0 {
+				t.scrollHistory(-int(t.config.Rows))
+				return
+			}
 		}
-		_, _ = t.in.Write([]byte{'\n'})
-	case fyne.KeyTab:
-		_, _ = t.in.Write([]byte{'\t'})
-	case fyne.KeyF1:
-		_, _ = t.in.Write([]byte{asciiEscape, 'O', 'P'})
-	case fyne.KeyF2:
-		_, _ = t.in.Write([]byte{asciiEscape, 'O', 'Q'})
-	case fyne.KeyF3:
-		_, _ = t.in.Write([]byte{asciiEscape, 'O', 'R'})
-	case fyne.KeyF4:
-		_, _ = t.in.Write([]byte{asciiEscape, 'O', 'S'})
-	case fyne.KeyF5:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', '1', '5', '~'})
-	case fyne.KeyF6:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', '1', '7', '~'})
-	case fyne.KeyF7:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', '1', '8', '~'})
-	case fyne.KeyF8:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', '1', '9', '~'})
-	case fyne.KeyF9:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', '2', '0', '~'})
-	case fyne.KeyF10:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', '2', '1', '~'})
-	case fyne.KeyF11:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', '2', '3', '~'})
-	case fyne.KeyF12:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', '2', '4', '~'})
-	case fyne.KeyEscape:
-		_, _ = t.in.Write([]byte{asciiEscape})
-	case fyne.KeyBackspace:
-		_, _ = t.in.Write([]byte{asciiBackspace})
-	case fyne.KeyDelete:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', '3', '~'})
-	case fyne.KeyUp, fyne.KeyDown, fyne.KeyLeft, fyne.KeyRight:
-		t.typeCursorKey(e.Name)
-	case fyne.KeyPageUp:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', '5', '~'})
-	case fyne.KeyPageDown:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', '6', '~'})
-	case fyne.KeyHome:
-		_, _ = t.in.Write([]byte{asciiEscape, 'O', 'H'})
-	case fyne.KeyInsert:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', '2', '~'})
-	case fyne.KeyEnd:
-		_, _ = t.in.Write([]byte{asciiEscape, 'O', 'F'})
 	}
-}
 
-func (t *Terminal) keyTypedWithShift(e *fyne.KeyEvent) {
-	switch e.Name {
-	case fyne.KeyF1:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', '2', '5', '~'})
-	case fyne.KeyF2:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', '2', '6', '~'})
-	case fyne.KeyF3:
-		_, _ = t.in.Write([]byte{asciiEscape, 'O', 'R', ';', '2', '~'})
-	case fyne.KeyF4:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', '1', ';', '2', 'S'})
-	case fyne.KeyF5:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', '1', '5', ';', '2', '~'})
-	case fyne.KeyF6:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', '1', '7', ';', '2', '~'})
-	case fyne.KeyF7:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', '1', '8', ';', '2', '~'})
-	case fyne.KeyF8:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', '1', '9', ';', '2', '~'})
-	case fyne.KeyF9:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', '2', '0', ';', '2', '~'})
-	case fyne.KeyF10:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', '2', '1', ';', '2', '~'})
-	case fyne.KeyF11:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', '2', '3', ';', '2', '~'})
-	case fyne.KeyF12:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', '2', '4', ';', '2', '~'})
-	case fyne.KeyPageUp:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', '5', ';', '2', '~'})
-	case fyne.KeyPageDown:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', '6', ';', '2', '~'})
-	case fyne.KeyHome:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', '1', ';', '2', 'H'})
-	case fyne.KeyInsert:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', '2', ';', '2', '~'})
-	case fyne.KeyDelete:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', '3', ';', '2', '~'})
-	case fyne.KeyEnd:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', '1', ';', '2', 'F'})
-	case fyne.KeyUp:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', 'A', ';', '2'})
-	case fyne.KeyDown:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', 'B', ';', '2'})
-	case fyne.KeyLeft:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', 'D', ';', '2'})
-	case fyne.KeyRight:
-		_, _ = t.in.Write([]byte{asciiEscape, '[', 'C', ';', '2'})
+	t.scrollToBottom()
+	if t.kittyFlags() != 0 && t.typeKittyKey(e.Name, mods) {
+		return
 	}
+	t.typeKey(e.Name, mods)
 }
 
 func (t *Terminal) trackKeyboardState(down bool, e *fyne.KeyEvent) {
@@ -141,43 +70,49 @@ func (t *Terminal) trackKeyboardState(down bool, e *fyne.KeyEvent) {
 		t.keyboardState.altPressed = down
 	case desktop.KeyControlRight:
 		t.keyboardState.ctrlPressed = down
+	case desktop.KeySuperLeft, desktop.KeySuperRight:
+		t.keyboardState.superPressed = down
 	}
 }
 
 // KeyDown is called when we get a down key event
 func (t *Terminal) KeyDown(e *fyne.KeyEvent) {
+	t.keyboardState.runeSent = false
 	t.trackKeyboardState(true, e)
+	t.keyboardState.lastDown = e.Name
 }
 
 // KeyUp is called when we get an up key event
 func (t *Terminal) KeyUp(e *fyne.KeyEvent) {
 	t.trackKeyboardState(false, e)
+	if t.kittyFlags() != 0 {
+		t.releaseKittyKey(e.Name)
+	}
 }
 
 // FocusGained notifies the terminal that it has focus
 func (t *Terminal) FocusGained() {
 	t.focused = true
+	if t.focusReporting {
+		t.reply("%c[I", asciiEscape)
+	}
 	t.Refresh()
 }
 
 // TypedShortcut handles key combinations, we pass them on to the tty.
 func (t *Terminal) TypedShortcut(s fyne.Shortcut) {
 	if ds, ok := s.(*desktop.CustomShortcut); ok {
+		if t.typePromptShortcut(ds) {
+			return
+		}
 		t.ShortcutHandler.TypedShortcut(s) // it's not clear how we can check if this consumed the event
 
-		// handle CTRL+A to CTRL+_ and everything in-between
-		if ds.Modifier == fyne.KeyModifierControl && len(ds.KeyName) > 0 {
-			char := ds.KeyName[0]
-			off := char - 'A' + 1
-			switch {
-			case ds.Key() == fyne.KeySpace:
-				fallthrough
-			case ds.Key() == "@":
-				off = 0
-				fallthrough
-			case char >= 'A' && char <= '_':
-				_, _ = t.in.Write([]byte{off})
-			}
+		if t.kittyFlags() != 0 && t.typeKittyKey(ds.KeyName, ds.Modifier) {
+			return
+		}
+		// typeModifiedRune handles CTRL+A to CTRL+_ and everything in-between
+		if !t.typeKey(ds.KeyName, ds.Modifier) && t.typeModifiedRune(ds.KeyName, ds.Modifier) {
+			t.keyboardState.runeSent = true // so a character typed with Alt is not sent again
 		}
 		return
 	}
@@ -210,6 +145,9 @@ func (t *Terminal) TypedShortcut(s fyne.Shortcut) {
 // FocusLost tells the terminal it no longer has focus
 func (t *Terminal) FocusLost() {
 	t.focused = false
+	if t.focusReporting {
+		t.reply("%c[O", asciiEscape)
+	}
 	t.Refresh()
 }
 
@@ -218,24 +156,6 @@ func (t *Terminal) Focused() bool {
 	return t.focused
 }
 
-func (t *Terminal) typeCursorKey(key fyne.KeyName) {
-	cursorPrefix := byte('[')
-	if t.bufferMode {
-		cursorPrefix = 'O'
-	}
-
-	switch key {
-	case fyne.KeyUp:
-		_, _ = t.in.Write([]byte{asciiEscape, cursorPrefix, 'A'})
-	case fyne.KeyDown:
-		_, _ = t.in.Write([]byte{asciiEscape, cursorPrefix, 'B'})
-	case fyne.KeyLeft:
-		_, _ = t.in.Write([]byte{asciiEscape, cursorPrefix, 'D'})
-	case fyne.KeyRight:
-		_, _ = t.in.Write([]byte{asciiEscape, cursorPrefix, 'C'})
-	}
-}
-
 type discardWriter struct{}
 
 func (d discardWriter) Write(p []byte) (n int, err error) {
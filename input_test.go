@@ -7,6 +7,7 @@ import (
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/driver/desktop"
+	"github.com/stretchr/testify/assert"
 )
 
 // NopCloser returns a WriteCloser with a no-op Close method wrapping
@@ -25,10 +26,10 @@ func (n nopCloser) Close() error {
 
 func TestTerminal_TypedKey(t *testing.T) {
 	tests := map[string]struct {
-		key          fyne.KeyName
-		bufferMode   bool
-		shiftPressed bool
-		want         []byte
+		key           fyne.KeyName
+		appCursorKeys bool
+		shiftPressed  bool
+		want          []byte
 	}{
 		"F1":             {fyne.KeyF1, false, false, []byte{asciiEscape, 'O', 'P'}},
 		"F2":             {fyne.KeyF2, false, false, []byte{asciiEscape, 'O', 'Q'}},
@@ -42,9 +43,9 @@ func TestTerminal_TypedKey(t *testing.T) {
 		"F10":            {fyne.KeyF10, false, false, []byte{asciiEscape, '[', '2', '1', '~'}},
 		"F11":            {fyne.KeyF11, false, false, []byte{asciiEscape, '[', '2', '3', '~'}},
 		"F12":            {fyne.KeyF12, false, false, []byte{asciiEscape, '[', '2', '4', '~'}},
-		"Shift+F1":       {fyne.KeyF1, false, true, []byte{asciiEscape, '[', '2', '5', '~'}},
-		"Shift+F2":       {fyne.KeyF2, false, true, []byte{asciiEscape, '[', '2', '6', '~'}},
-		"Shift+F3":       {fyne.KeyF3, false, true, []byte{asciiEscape, 'O', 'R', ';', '2', '~'}},
+		"Shift+F1":       {fyne.KeyF1, false, true, []byte{asciiEscape, '[', '1', ';', '2', 'P'}},
+		"Shift+F2":       {fyne.KeyF2, false, true, []byte{asciiEscape, '[', '1', ';', '2', 'Q'}},
+		"Shift+F3":       {fyne.KeyF3, false, true, []byte{asciiEscape, '[', '1', ';', '2', 'R'}},
 		"Shift+F4":       {fyne.KeyF4, false, true, []byte{asciiEscape, '[', '1', ';', '2', 'S'}},
 		"Shift+F5":       {fyne.KeyF5, false, true, []byte{asciiEscape, '[', '1', '5', ';', '2', '~'}},
 		"Shift+F6":       {fyne.KeyF6, false, true, []byte{asciiEscape, '[', '1', '7', ';', '2', '~'}},
@@ -60,18 +61,20 @@ func TestTerminal_TypedKey(t *testing.T) {
 		"Shift+Insert":   {fyne.KeyInsert, false, true, []byte{asciiEscape, '[', '2', ';', '2', '~'}},
 		"Shift+Delete":   {fyne.KeyDelete, false, true, []byte{asciiEscape, '[', '3', ';', '2', '~'}},
 		"Shift+End":      {fyne.KeyEnd, false, true, []byte{asciiEscape, '[', '1', ';', '2', 'F'}},
-		"Shift+Up":       {fyne.KeyUp, false, true, []byte{asciiEscape, '[', 'A', ';', '2'}},
-		"Shift+Down":     {fyne.KeyDown, false, true, []byte{asciiEscape, '[', 'B', ';', '2'}},
-		"Shift+Left":     {fyne.KeyLeft, false, true, []byte{asciiEscape, '[', 'D', ';', '2'}},
-		"Shift+Right":    {fyne.KeyRight, false, true, []byte{asciiEscape, '[', 'C', ';', '2'}},
+		"Shift+Up":       {fyne.KeyUp, false, true, []byte{asciiEscape, '[', '1', ';', '2', 'A'}},
+		"Shift+Down":     {fyne.KeyDown, false, true, []byte{asciiEscape, '[', '1', ';', '2', 'B'}},
+		"Shift+Left":     {fyne.KeyLeft, false, true, []byte{asciiEscape, '[', '1', ';', '2', 'D'}},
+		"Shift+Right":    {fyne.KeyRight, false, true, []byte{asciiEscape, '[', '1', ';', '2', 'C'}},
+		"Shift+Tab":      {fyne.KeyTab, false, true, []byte{asciiEscape, '[', 'Z'}},
+		"Shift+Return":   {fyne.KeyReturn, false, true, []byte{'\r'}},
 
 		"PageUp":    {fyne.KeyPageUp, false, false, []byte{asciiEscape, '[', '5', '~'}},
 		"PageDown":  {fyne.KeyPageDown, false, false, []byte{asciiEscape, '[', '6', '~'}},
-		"Home":      {fyne.KeyHome, false, false, []byte{asciiEscape, 'O', 'H'}},
+		"Home":      {fyne.KeyHome, false, false, []byte{asciiEscape, '[', 'H'}},
 		"Insert":    {fyne.KeyInsert, false, false, []byte{asciiEscape, '[', '2', '~'}},
 		"Delete":    {fyne.KeyDelete, false, false, []byte{asciiEscape, '[', '3', '~'}},
-		"End":       {fyne.KeyEnd, false, false, []byte{asciiEscape, 'O', 'F'}},
-		"Enter":     {fyne.KeyEnter, false, false, []byte{'\n'}}, // Modify as needed for Windows or bufferMode
+		"End":       {fyne.KeyEnd, false, false, []byte{asciiEscape, '[', 'F'}},
+		"Enter":     {fyne.KeyEnter, false, false, []byte{'\n'}}, // Modify as needed for Windows
 		"Tab":       {fyne.KeyTab, false, false, []byte{'\t'}},
 		"Escape":    {fyne.KeyEscape, false, false, []byte{asciiEscape}},
 		"Backspace": {fyne.KeyBackspace, false, false, []byte{asciiBackspace}},
@@ -79,13 +82,18 @@ func TestTerminal_TypedKey(t *testing.T) {
 		"Down":      {fyne.KeyDown, false, false, []byte{asciiEscape, '[', 'B'}},
 		"Left":      {fyne.KeyLeft, false, false, []byte{asciiEscape, '[', 'D'}},
 		"Right":     {fyne.KeyRight, false, false, []byte{asciiEscape, '[', 'C'}},
+
+		"Application Up":       {fyne.KeyUp, true, false, []byte{asciiEscape, 'O', 'A'}},
+		"Application Home":     {fyne.KeyHome, true, false, []byte{asciiEscape, 'O', 'H'}},
+		"Application End":      {fyne.KeyEnd, true, false, []byte{asciiEscape, 'O', 'F'}},
+		"Application Shift+Up": {fyne.KeyUp, true, true, []byte{asciiEscape, '[', '1', ';', '2', 'A'}},
 	}
 
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
 			// Creating a mock terminal
 			inBuffer := bytes.NewBuffer([]byte{})
-			term := &Terminal{in: NopCloser(inBuffer), bufferMode: tt.bufferMode}
+			term := &Terminal{in: NopCloser(inBuffer), appCursorKeys: tt.appCursorKeys}
 			term.keyboardState.shiftPressed = tt.shiftPressed
 			keyEvent := &fyne.KeyEvent{Name: tt.key}
 
@@ -170,6 +178,24 @@ func TestTerminal_TypedShortcut(t *testing.T) {
 				KeyName:  fyne.KeyX},
 			want: []byte{24},
 		},
+		"Control+Up": {
+			shortcut: &desktop.CustomShortcut{
+				Modifier: fyne.KeyModifierControl,
+				KeyName:  fyne.KeyUp},
+			want: []byte{asciiEscape, '[', '1', ';', '5', 'A'},
+		},
+		"Alt+Left": {
+			shortcut: &desktop.CustomShortcut{
+				Modifier: fyne.KeyModifierAlt,
+				KeyName:  fyne.KeyLeft},
+			want: []byte{asciiEscape, '[', '1', ';', '3', 'D'},
+		},
+		"Control+Shift+End": {
+			shortcut: &desktop.CustomShortcut{
+				Modifier: fyne.KeyModifierControl | fyne.KeyModifierShift,
+				KeyName:  fyne.KeyEnd},
+			want: []byte{asciiEscape, '[', '1', ';', '6', 'F'},
+		},
 	}
 
 	for name, tt := range tests {
@@ -187,3 +213,139 @@ func TestTerminal_TypedShortcut(t *testing.T) {
 		})
 	}
 }
+
+func TestTerminal_TypedKey_Keypad(t *testing.T) {
+	var keypad7 fyne.HardwareKey
+	for code, final := range keypadKeys {
+		if final == 'w' {
+			keypad7.ScanCode = code
+		}
+	}
+
+	inBuffer := bytes.NewBuffer([]byte{})
+	term := New()
+	term.in = NopCloser(inBuffer)
+	term.config.Columns = 5
+	term.config.Rows = 2
+	term.Refresh() // ensure visuals set up
+
+	term.KeyDown(&fyne.KeyEvent{Name: fyne.Key7, Physical: keypad7})
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.Key7, Physical: keypad7})
+	term.TypedRune('7')
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.KeyEnter})
+	assert.Equal(t, "7\n", inBuffer.String())
+
+	inBuffer.Reset()
+	term.handleOutput([]byte(esc("=")))
+	term.KeyDown(&fyne.KeyEvent{Name: fyne.Key7, Physical: keypad7})
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.Key7, Physical: keypad7})
+	term.TypedRune('7')
+	term.KeyDown(&fyne.KeyEvent{Name: fyne.Key7})
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.Key7})
+	term.TypedRune('7')
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.KeyEnter})
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+	assert.Equal(t, esc("Ow")+"7"+esc("OM")+"\r", inBuffer.String())
+
+	inBuffer.Reset()
+	term.handleOutput([]byte(esc(">")))
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.KeyEnter})
+	assert.Equal(t, "\n", inBuffer.String())
+}
+
+func TestTerminal_TypedShortcut_Modifiers(t *testing.T) {
+	tests := map[string]struct {
+		shortcut        *desktop.CustomShortcut
+		altSendsEscape  bool
+		modifyOtherKeys int
+		want            string
+	}{
+		"Control+F5":           {shortcut: &desktop.CustomShortcut{Modifier: fyne.KeyModifierControl, KeyName: fyne.KeyF5}, want: esc("[15;5~")},
+		"Alt+PageUp":           {shortcut: &desktop.CustomShortcut{Modifier: fyne.KeyModifierAlt, KeyName: fyne.KeyPageUp}, want: esc("[5;3~")},
+		"Control+Alt+F1":       {shortcut: &desktop.CustomShortcut{Modifier: fyne.KeyModifierControl | fyne.KeyModifierAlt, KeyName: fyne.KeyF1}, want: esc("[1;7P")},
+		"Control+Shift+Delete": {shortcut: &desktop.CustomShortcut{Modifier: fyne.KeyModifierControl | fyne.KeyModifierShift, KeyName: fyne.KeyDelete}, want: esc("[3;6~")},
+		"Control+Return":       {shortcut: &desktop.CustomShortcut{Modifier: fyne.KeyModifierControl, KeyName: fyne.KeyReturn}, want: "\r"},
+		"Alt+A":                {shortcut: &desktop.CustomShortcut{Modifier: fyne.KeyModifierAlt, KeyName: fyne.KeyA}, want: ""},
+		"Alt+A as meta":        {shortcut: &desktop.CustomShortcut{Modifier: fyne.KeyModifierAlt, KeyName: fyne.KeyA}, altSendsEscape: true, want: esc("a")},
+		"Alt+Shift+A as meta":  {shortcut: &desktop.CustomShortcut{Modifier: fyne.KeyModifierAlt | fyne.KeyModifierShift, KeyName: fyne.KeyA}, altSendsEscape: true, want: esc("A")},
+		"Control+Alt+C as meta": {shortcut: &desktop.CustomShortcut{Modifier: fyne.KeyModifierControl | fyne.KeyModifierAlt, KeyName: fyne.KeyC}, altSendsEscape: true,
+			want: esc("\x03")},
+		"Control+Return modified": {shortcut: &desktop.CustomShortcut{Modifier: fyne.KeyModifierControl, KeyName: fyne.KeyReturn}, modifyOtherKeys: 1,
+			want: esc("[27;5;13~")},
+		"Control+Tab modified": {shortcut: &desktop.CustomShortcut{Modifier: fyne.KeyModifierControl, KeyName: fyne.KeyTab}, modifyOtherKeys: 2,
+			want: esc("[27;5;9~")},
+		"Shift+Tab modified": {shortcut: &desktop.CustomShortcut{Modifier: fyne.KeyModifierShift, KeyName: fyne.KeyTab}, modifyOtherKeys: 2,
+			want: esc("[Z")},
+		"Control+A modified 1": {shortcut: &desktop.CustomShortcut{Modifier: fyne.KeyModifierControl, KeyName: fyne.KeyA}, modifyOtherKeys: 1,
+			want: "\x01"},
+		"Control+A modified 2": {shortcut: &desktop.CustomShortcut{Modifier: fyne.KeyModifierControl, KeyName: fyne.KeyA}, modifyOtherKeys: 2,
+			want: esc("[27;5;97~")},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			inBuffer := bytes.NewBuffer([]byte{})
+			term := &Terminal{in: NopCloser(inBuffer), modifyOtherKeys: tt.modifyOtherKeys}
+			term.SetAltSendsEscape(tt.altSendsEscape)
+
+			term.TypedShortcut(tt.shortcut)
+			assert.Equal(t, tt.want, inBuffer.String())
+		})
+	}
+}
+
+func TestTerminal_TypedRune_AltSendsEscape(t *testing.T) {
+	inBuffer := bytes.NewBuffer([]byte{})
+	term := &Terminal{in: NopCloser(inBuffer)}
+	term.SetAltSendsEscape(true)
+
+	term.KeyDown(&fyne.KeyEvent{Name: desktop.KeyAltLeft})
+	term.KeyDown(&fyne.KeyEvent{Name: fyne.KeyA})
+	term.TypedShortcut(&desktop.CustomShortcut{Modifier: fyne.KeyModifierAlt, KeyName: fyne.KeyA})
+	term.TypedRune('a')
+	assert.Equal(t, esc("a"), inBuffer.String())
+
+	inBuffer.Reset()
+	term.KeyDown(&fyne.KeyEvent{Name: desktop.KeyControlLeft})
+	term.KeyDown(&fyne.KeyEvent{Name: fyne.Key7})
+	term.TypedShortcut(&desktop.CustomShortcut{Modifier: fyne.KeyModifierControl | fyne.KeyModifierAlt, KeyName: fyne.Key7})
+	term.TypedRune('{') // AltGr+7 on a German layout
+	assert.Equal(t, "{", inBuffer.String())
+}
+
+func TestModifyOtherKeys(t *testing.T) {
+	term := New()
+	term.config.Columns = 5
+	term.config.Rows = 2
+	term.Refresh() // ensure visuals set up
+
+	term.handleOutput([]byte(esc("[>4;2m")))
+	assert.Equal(t, 2, term.modifyOtherKeys)
+	term.handleOutput([]byte(esc("[>4;1m")))
+	assert.Equal(t, 1, term.modifyOtherKeys)
+	term.handleOutput([]byte(esc("[>4n")))
+	assert.Equal(t, 0, term.modifyOtherKeys)
+}
+
+func TestTerminal_FocusReporting(t *testing.T) {
+	inBuffer := bytes.NewBuffer([]byte{})
+	term := New()
+	term.in = NopCloser(inBuffer)
+	term.config.Columns = 5
+	term.config.Rows = 2
+	term.Refresh() // ensure visuals set up
+
+	term.FocusGained()
+	term.FocusLost()
+	assert.Equal(t, "", inBuffer.String())
+
+	term.handleOutput([]byte(esc("[?1004h")))
+	term.FocusGained()
+	term.FocusLost()
+	assert.Equal(t, esc("[I")+esc("[O"), inBuffer.String())
+
+	inBuffer.Reset()
+	term.handleOutput([]byte(esc("[?1004l")))
+	term.FocusGained()
+	assert.Equal(t, "", inBuffer.String())
+}
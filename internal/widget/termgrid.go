@@ -2,9 +2,13 @@ package widget
 
 import (
 	"context"
+	"image/color"
+	"math"
 	"time"
 
+	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
 	"fyne.io/fyne/v2"
@@ -17,6 +21,10 @@ const blinkingInterval = 500 * time.Millisecond
 type TermGrid struct {
 	widget.TextGrid
 
+	// RowInfos holds what is known about each row beyond its cells, indexed like Rows.
+	// It may be shorter than Rows, the missing rows have an empty RowInfo.
+	RowInfos []RowInfo
+
 	tickerCancel context.CancelFunc
 }
 
@@ -24,7 +32,7 @@ type TermGrid struct {
 func (t *TermGrid) CreateRenderer() fyne.WidgetRenderer {
 	t.ExtendBaseWidget(t)
 
-	return t.TextGrid.CreateRenderer()
+	return &termGridRenderer{WidgetRenderer: t.TextGrid.CreateRenderer(), grid: t}
 }
 
 // NewTermGrid creates a new empty TextGrid widget.
@@ -36,6 +44,44 @@ func NewTermGrid() *TermGrid {
 	return grid
 }
 
+// Text returns the contents of the grid as a single string, rows that were wrapped are joined
+// back into one line and other rows are separated by `\n`.
+func (t *TermGrid) Text() string {
+	var runes []rune
+	for i, row := range t.Rows {
+		for _, cell := range row.Cells {
+			runes = appendCellText(runes, cell)
+		}
+		if i < len(t.Rows)-1 && !t.RowInfo(i).Wrapped {
+			runes = append(runes, '\n')
+		}
+	}
+
+	return string(runes)
+}
+
+// RowInfo returns what is known about the given row, which is empty if nothing has been set.
+func (t *TermGrid) RowInfo(row int) RowInfo {
+	if row < 0 || row >= len(t.RowInfos) {
+		return RowInfo{}
+	}
+	return t.RowInfos[row]
+}
+
+// SetRowInfo updates what is known about the given row.
+func (t *TermGrid) SetRowInfo(row int, info RowInfo) {
+	if row < 0 {
+		return
+	}
+	if row >= len(t.RowInfos) {
+		if info.empty() {
+			return
+		}
+		t.RowInfos = append(t.RowInfos, make([]RowInfo, row+1-len(t.RowInfos))...)
+	}
+	t.RowInfos[row] = info
+}
+
 // Refresh will be called when this grid should update.
 // We update our blinking status and then call the TextGrid we extended to refresh too.
 func (t *TermGrid) Refresh() {
@@ -49,7 +95,12 @@ func (t *TermGrid) refreshBlink(blink bool) {
 
 	for _, row := range t.Rows {
 		for _, r := range row.Cells {
-			if s, ok := r.Style.(*TermTextGridStyle); ok && s != nil && s.BlinkEnabled {
+			s, ok := r.Style.(*TermTextGridStyle)
+			if !ok || s == nil {
+				continue
+			}
+			s.theme = t.Theme()
+			if s.BlinkEnabled {
 				shouldBlink = true
 
 				s.blink(blink)
@@ -90,3 +141,175 @@ func (t *TermGrid) runBlink() {
 		}
 	}()
 }
+
+// termGridRenderer extends the TextGrid renderer to draw the text decorations that it does not support.
+type termGridRenderer struct {
+	fyne.WidgetRenderer
+	grid *TermGrid
+
+	rows []decorationRow
+}
+
+// decorationRow holds the lines drawn over one row of text, and what they were drawn for.
+// Lines are reused between refreshes and any that are no longer needed are hidden.
+type decorationRow struct {
+	decorations []decoration
+	lines       []*canvas.Line
+}
+
+// decoration is a line to draw, such as an underline. Straight lines cover a whole run of cells.
+type decoration struct {
+	x1, y1, x2, y2 float32
+	color          color.Color
+}
+
+func (r *termGridRenderer) Layout(s fyne.Size) {
+	r.WidgetRenderer.Layout(s)
+	r.refreshDecorations()
+}
+
+func (r *termGridRenderer) Objects() []fyne.CanvasObject {
+	objs := r.WidgetRenderer.Objects()
+	objs = objs[:len(objs):len(objs)]
+	for _, row := range r.rows {
+		for _, l := range row.lines {
+			objs = append(objs, l)
+		}
+	}
+	return objs
+}
+
+func (r *termGridRenderer) Refresh() {
+	r.WidgetRenderer.Refresh()
+	r.refreshDecorations()
+}
+
+// refreshDecorations places the lines for underlined, struck through or overlined text.
+// Hyperlinks are underlined while the mouse is over them.
+// Only the rows whose decorations changed since the last refresh are redrawn.
+func (r *termGridRenderer) refreshDecorations() {
+	th := r.grid.Theme()
+	cell := fyne.MeasureText("M", th.Size(theme.SizeNameText), fyne.TextStyle{Monospace: true})
+	cell.Width = float32(math.Round(float64(cell.Width)))
+	cell.Height = float32(math.Round(float64(cell.Height)))
+
+	for len(r.rows) < len(r.grid.Rows) {
+		r.rows = append(r.rows, decorationRow{})
+	}
+	for y := range r.rows {
+		var decorations []decoration
+		if y < len(r.grid.Rows) {
+			decorations = r.rowDecorations(r.grid.Rows[y], float32(y)*cell.Height, cell, th)
+		}
+		r.rows[y].update(decorations)
+	}
+}
+
+// rowDecorations returns the lines to draw over a row of text that starts at the given top.
+func (r *termGridRenderer) rowDecorations(row widget.TextGridRow, top float32, cell fyne.Size, th fyne.Theme) []decoration {
+	var lines []decoration
+	addLine := func(x1, y1, x2, y2 float32, c color.Color) {
+		if y1 == y2 { // join up with the same line in the cell to the left
+			for i := len(lines) - 1; i >= 0; i-- {
+				l := &lines[i]
+				if l.y1 == y1 && l.y2 == y2 && l.x2 == x1 && sameColor(l.color, c) {
+					l.x2 = x2
+					return
+				}
+			}
+		}
+		lines = append(lines, decoration{x1: x1, y1: y1, x2: x2, y2: y2, color: c})
+	}
+
+	for x, c := range row.Cells {
+		s, ok := c.Style.(*TermTextGridStyle)
+		if !ok || s.Attributes&AttributeHidden != 0 {
+			continue
+		}
+		hovered := s.Link != nil && s.Link.Hovered
+		if !hovered && s.Attributes&(AttributeUnderline|AttributeStrikethrough|AttributeOverline) == 0 {
+			continue
+		}
+
+		fg := s.TextColor()
+		if fg == nil {
+			fg = th.Color(theme.ColorNameForeground, fyne.CurrentApp().Settings().ThemeVariant())
+		}
+		left := float32(x) * cell.Width
+		right, bottom := left+cell.Width, top+cell.Height-1
+		if s.Attributes&AttributeUnderline != 0 {
+			ul := fg
+			if s.UnderlineColor != nil {
+				ul = s.UnderlineColor
+			}
+
+			switch s.UnderlineStyle {
+			case UnderlineDouble:
+				addLine(left, bottom, right, bottom, ul)
+				addLine(left, bottom-2, right, bottom-2, ul)
+			case UnderlineCurly:
+				mid := left + cell.Width/2
+				addLine(left, bottom, mid, bottom-2, ul)
+				addLine(mid, bottom-2, right, bottom, ul)
+			case UnderlineDotted:
+				dot := cell.Width / 4
+				addLine(left, bottom, left+dot, bottom, ul)
+				addLine(left+dot*2, bottom, left+dot*3, bottom, ul)
+			case UnderlineDashed:
+				addLine(left, bottom, left+cell.Width*2/3, bottom, ul)
+			default:
+				addLine(left, bottom, right, bottom, ul)
+			}
+		} else if hovered {
+			addLine(left, bottom, right, bottom, fg)
+		}
+		if s.Attributes&AttributeStrikethrough != 0 {
+			addLine(left, top+cell.Height/2, right, top+cell.Height/2, fg)
+		}
+		if s.Attributes&AttributeOverline != 0 {
+			addLine(left, top+1, right, top+1, fg)
+		}
+	}
+	return lines
+}
+
+// update shows the given decorations on the row, unless they are already shown.
+func (d *decorationRow) update(decorations []decoration) {
+	if d.shows(decorations) {
+		return
+	}
+
+	for i, dec := range decorations {
+		if i == len(d.lines) {
+			d.lines = append(d.lines, canvas.NewLine(dec.color))
+		}
+		l := d.lines[i]
+		l.StrokeColor, l.StrokeWidth = dec.color, 1
+		l.Position1, l.Position2 = fyne.NewPos(dec.x1, dec.y1), fyne.NewPos(dec.x2, dec.y2)
+		l.Show()
+		l.Refresh()
+	}
+	for _, l := range d.lines[len(decorations):] {
+		l.Hide()
+	}
+	d.decorations = decorations
+}
+
+func (d *decorationRow) shows(decorations []decoration) bool {
+	if len(d.decorations) != len(decorations) {
+		return false
+	}
+	for i, dec := range decorations {
+		old := d.decorations[i]
+		if old.x1 != dec.x1 || old.y1 != dec.y1 || old.x2 != dec.x2 || old.y2 != dec.y2 || !sameColor(old.color, dec.color) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameColor(a, b color.Color) bool {
+	ar, ag, ab, aa := a.RGBA()
+	br, bg, bb, ba := b.RGBA()
+	return ar == br && ag == bg && ab == bb && aa == ba
+}
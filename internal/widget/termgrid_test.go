@@ -0,0 +1,139 @@
+package widget
+
+import (
+	"image/color"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+)
+
+func TestTermGrid_Decorations(t *testing.T) {
+	test.NewApp()
+	grid := NewTermGrid()
+	grid.Resize(fyne.NewSize(100, 100))
+	style := NewTermTextGridStyle(nil, nil, 0xAA, false).(*TermTextGridStyle)
+	style.Attributes = AttributeUnderline | AttributeStrikethrough
+	grid.Rows = []widget.TextGridRow{
+		{Cells: []widget.TextGridCell{{Rune: 'A', Style: style}, {Rune: 'B'}}},
+	}
+
+	r := test.TempWidgetRenderer(t, grid).(*termGridRenderer)
+	r.Refresh()
+	if got := visibleLines(r.Objects()); got != 2 {
+		t.Fatalf("visible decorations = %d; want 2", got)
+	}
+
+	style.Attributes = AttributeOverline
+	r.Refresh()
+	if got := visibleLines(r.Objects()); got != 1 {
+		t.Fatalf("visible decorations = %d; want 1", got)
+	}
+}
+
+func visibleLines(objs []fyne.CanvasObject) int {
+	count := 0
+	for _, o := range objs {
+		if _, ok := o.(*canvas.Line); ok && o.Visible() {
+			count++
+		}
+	}
+	return count
+}
+
+func TestTermGrid_UnderlineStyles(t *testing.T) {
+	test.NewApp()
+	grid := NewTermGrid()
+	grid.Resize(fyne.NewSize(100, 100))
+	style := NewTermTextGridStyle(nil, nil, 0xAA, false).(*TermTextGridStyle)
+	style.Attributes = AttributeUnderline
+	style.UnderlineColor = color.RGBA{R: 255, A: 255}
+	grid.Rows = []widget.TextGridRow{
+		{Cells: []widget.TextGridCell{{Rune: 'A', Style: style}}},
+	}
+	r := test.TempWidgetRenderer(t, grid).(*termGridRenderer)
+
+	tests := map[string]struct {
+		style UnderlineStyle
+		lines int
+	}{
+		"single": {UnderlineSingle, 1},
+		"double": {UnderlineDouble, 2},
+		"curly":  {UnderlineCurly, 2},
+		"dotted": {UnderlineDotted, 2},
+		"dashed": {UnderlineDashed, 1},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			style.UnderlineStyle = tt.style
+			r.Refresh()
+			if got := visibleLines(r.Objects()); got != tt.lines {
+				t.Fatalf("visible decorations = %d; want %d", got, tt.lines)
+			}
+			if got := r.rows[0].lines[0].StrokeColor; got != style.UnderlineColor {
+				t.Errorf("underline colour = %v; want %v", got, style.UnderlineColor)
+			}
+		})
+	}
+}
+
+func TestTermGrid_HoveredLink(t *testing.T) {
+	test.NewApp()
+	grid := NewTermGrid()
+	grid.Resize(fyne.NewSize(100, 100))
+	link := &Hyperlink{URI: "https://example.com"}
+	style := NewTermTextGridStyle(nil, nil, 0xAA, false).(*TermTextGridStyle)
+	style.Link = link
+	grid.Rows = []widget.TextGridRow{
+		{Cells: []widget.TextGridCell{{Rune: 'A', Style: style}, {Rune: 'B', Style: style}}},
+		{Cells: []widget.TextGridCell{{Rune: 'C', Style: style}}},
+	}
+
+	r := test.TempWidgetRenderer(t, grid).(*termGridRenderer)
+	r.Refresh()
+	if got := visibleLines(r.Objects()); got != 0 {
+		t.Fatalf("visible decorations = %d; want 0", got)
+	}
+
+	link.Hovered = true
+	r.Refresh()
+	if got := visibleLines(r.Objects()); got != 2 { // one line for each run of linked cells
+		t.Fatalf("visible decorations = %d; want 2", got)
+	}
+}
+
+func TestTermGrid_DecorationRuns(t *testing.T) {
+	test.NewApp()
+	grid := NewTermGrid()
+	grid.Resize(fyne.NewSize(100, 100))
+	red := NewTermTextGridStyle(color.RGBA{R: 255, A: 255}, nil, 0xAA, false).(*TermTextGridStyle)
+	red.Attributes = AttributeUnderline
+	blue := NewTermTextGridStyle(color.RGBA{B: 255, A: 255}, nil, 0xAA, false).(*TermTextGridStyle)
+	blue.Attributes = AttributeUnderline
+	grid.Rows = []widget.TextGridRow{
+		{Cells: []widget.TextGridCell{{Rune: 'A', Style: red}, {Rune: 'B', Style: red}, {Rune: 'C', Style: blue}}},
+		{Cells: []widget.TextGridCell{{Rune: 'D', Style: blue}}},
+	}
+
+	r := test.TempWidgetRenderer(t, grid).(*termGridRenderer)
+	r.Refresh()
+	if got := visibleLines(r.Objects()); got != 3 {
+		t.Fatalf("visible decorations = %d; want 3", got)
+	}
+	run, single := r.rows[0].lines[0], r.rows[0].lines[1]
+	if got, want := run.Position2.X-run.Position1.X, 2*(single.Position2.X-single.Position1.X); got != want {
+		t.Errorf("run width = %v; want %v", got, want)
+	}
+
+	unchanged := &r.rows[1].decorations[0]
+	grid.Rows[0].Cells[1].Style = blue
+	r.Refresh()
+	if got := visibleLines(r.Objects()); got != 3 {
+		t.Fatalf("visible decorations = %d; want 3", got)
+	}
+	if &r.rows[1].decorations[0] != unchanged {
+		t.Error("row without changes was redrawn")
+	}
+}
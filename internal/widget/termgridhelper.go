@@ -40,6 +40,40 @@ func ClearHighlightRange(t *TermGrid, blockMode bool, startRow, startCol, endRow
 	forRange(t, blockMode, startRow, startCol, endRow, endCol, clearHighlight, nil)
 }
 
+// ReplaceColors changes every use of the colours that are keys of replacements in the given rows to their values.
+// Colours are matched by identity, so that a palette entry can be changed without affecting RGB colours that look the same.
+// All the colours are replaced in one pass, so swapping two colours works as expected.
+func ReplaceColors(rows []widget.TextGridRow, replacements map[color.Color]color.Color, bitmask byte) {
+	replace := func(col *color.Color) bool {
+		if *col == nil {
+			return false
+		}
+		c, ok := replacements[*col]
+		if ok {
+			*col = c
+		}
+		return ok
+	}
+
+	for i := range rows {
+		for j := range rows[i].Cells {
+			switch s := rows[i].Cells[j].Style.(type) {
+			case *widget.CustomTextGridStyle:
+				replace(&s.FGColor)
+				replace(&s.BGColor)
+			case *TermTextGridStyle:
+				if replace(&s.OriginalTextColor) {
+					s.InvertedTextColor = invertColor(s.OriginalTextColor, bitmask)
+				}
+				if replace(&s.OriginalBackgroundColor) {
+					s.InvertedBackgroundColor = invertColor(s.OriginalBackgroundColor, bitmask)
+				}
+				replace(&s.UnderlineColor)
+			}
+		}
+	}
+}
+
 // GetTextRange retrieves a text range from the TextGrid. It collects the text
 // within the specified grid coordinates, starting from (startRow, startCol) and
 // ending at (endRow, endCol), and returns it as a string. The behavior of the
@@ -57,13 +91,22 @@ func ClearHighlightRange(t *TermGrid, blockMode bool, startRow, startCol, endRow
 //
 // Returns:
 //   - string: The text content within the specified range as a string.
+//
+// Outside of block mode rows that were wrapped are joined with the following row instead of a newline.
 func GetTextRange(t *TermGrid, blockMode bool, startRow, startCol, endRow, endCol int) string {
 	var result []rune
 
+	prevRow := startRow
+	if prevRow < 0 {
+		prevRow = 0
+	}
 	forRange(t, blockMode, startRow, startCol, endRow, endCol, func(cell *widget.TextGridCell) {
-		result = append(result, cell.Rune)
+		result = appendCellText(result, *cell)
 	}, func(row *widget.TextGridRow) {
-		result = append(result, '\n')
+		if blockMode || !t.RowInfo(prevRow).Wrapped {
+			result = append(result, '\n')
+		}
+		prevRow++
 	})
 
 	return string(result)
@@ -165,9 +208,71 @@ func forRange(t *TermGrid, blockMode bool, startRow, startCol, endRow, endCol in
 	}
 }
 
+// RowInfo is what is known about a row of a TermGrid beyond its cells, it moves with the row as the text scrolls.
+type RowInfo struct {
+	// Wrapped is set if the text of the row continues on the next row
+	// because it was automatically wrapped at the right edge of the grid.
+	Wrapped bool
+	// Marks are the semantic prompt marks (OSC 133) printed on the row, in the order they were printed.
+	Marks []PromptMark
+}
+
+func (r RowInfo) empty() bool {
+	return !r.Wrapped && len(r.Marks) == 0
+}
+
+// PromptMark records where a part of a shell command starts, as marked by OSC 133.
+type PromptMark struct {
+	Col int
+	// Zone is the part of the command that starts at Col, or ZoneNone where the command finished.
+	Zone    CommandZone
+	Command *CommandMark
+}
+
+// TextAttribute is a set of character attributes, as selected by SGR escape codes, that apply to a cell.
+type TextAttribute uint16
+
+const (
+	// AttributeBold draws the text with a heavier weight.
+	AttributeBold TextAttribute = 1 << iota
+	// AttributeDim draws the text with reduced intensity.
+	AttributeDim
+	// AttributeItalic draws the text slanted.
+	AttributeItalic
+	// AttributeUnderline draws a line under the text.
+	AttributeUnderline
+	// AttributeHidden conceals the text, it is drawn in the background colour.
+	AttributeHidden
+	// AttributeStrikethrough draws a line through the middle of the text.
+	AttributeStrikethrough
+	// AttributeOverline draws a line above the text.
+	AttributeOverline
+	// AttributeInverse swaps the text and background colours when the cell is drawn.
+	AttributeInverse
+)
+
+// UnderlineStyle selects how an underline is drawn, as chosen by the SGR 4:x sub-parameter.
+type UnderlineStyle uint8
+
+const (
+	// UnderlineSingle is a straight line, the default underline.
+	UnderlineSingle UnderlineStyle = iota
+	// UnderlineDouble is two straight lines.
+	UnderlineDouble
+	// UnderlineCurly is a wavy line, often used for spelling or diagnostic errors.
+	UnderlineCurly
+	// UnderlineDotted is a line of dots.
+	UnderlineDotted
+	// UnderlineDashed is a line of dashes.
+	UnderlineDashed
+)
+
 // TermTextGridStyle defines a style that can be original or highlighted.
 type TermTextGridStyle struct {
 	TextStyle               fyne.TextStyle
+	Attributes              TextAttribute
+	UnderlineStyle          UnderlineStyle
+	UnderlineColor          color.Color // if nil the underline is drawn in the text colour
 	OriginalTextColor       color.Color
 	OriginalBackgroundColor color.Color
 	InvertedTextColor       color.Color
@@ -175,38 +280,157 @@ type TermTextGridStyle struct {
 	Highlighted             bool
 	BlinkEnabled            bool
 	blinked                 bool
+	theme                   fyne.Theme // the theme of the grid, which gives the default colours
+
+	// Combining holds the runes following the cell rune in the same grapheme cluster,
+	// such as combining accents or the parts of a joined emoji sequence.
+	Combining []rune
+	// Continuation marks the cell to the right of a double width character, it has no content of its own.
+	Continuation bool
+	// Link is the hyperlink that the cell is part of, or nil.
+	Link *Hyperlink
+}
+
+// CommandMark identifies a shell command, it is shared by all the prompt marks of the command.
+type CommandMark struct {
+	// ExitCode is the status that the command finished with, if Finished is set.
+	// It is -1 if the shell did not report a valid status.
+	ExitCode int
+	Finished bool
+}
+
+// CommandZone is the part of a shell command that some text belongs to.
+type CommandZone uint8
+
+const (
+	// ZonePrompt is the prompt printed by the shell.
+	ZonePrompt CommandZone = iota
+	// ZoneInput is the command line typed by the user.
+	ZoneInput
+	// ZoneOutput is the output of the command.
+	ZoneOutput
+	// ZoneNone is text after the command finished, that is not part of any command.
+	ZoneNone
+)
+
+// Hyperlink is a link set by an OSC 8 sequence, it is shared by all the cells of the link.
+type Hyperlink struct {
+	ID, URI string
+	// Hovered is set while the mouse is over any part of the link, which is then underlined.
+	Hovered bool
+}
+
+// IsContinuation returns true if the cell is covered by the double width character to its left.
+func IsContinuation(cell widget.TextGridCell) bool {
+	s, ok := cell.Style.(*TermTextGridStyle)
+	return ok && s.Continuation
+}
+
+// appendCellText adds the text of a cell to the runes passed in, including any combining runes.
+// Cells that continue a double width character add nothing.
+func appendCellText(runes []rune, cell widget.TextGridCell) []rune {
+	s, ok := cell.Style.(*TermTextGridStyle)
+	if !ok {
+		return append(runes, cell.Rune)
+	}
+	if s.Continuation {
+		return runes
+	}
+	return append(append(runes, cell.Rune), s.Combining...)
 }
 
 // Style is the text style a cell should use.
 func (h *TermTextGridStyle) Style() fyne.TextStyle {
-	return h.TextStyle
+	style := h.TextStyle
+	style.Bold = style.Bold || h.Attributes&AttributeBold != 0
+	style.Italic = style.Italic || h.Attributes&AttributeItalic != 0
+	return style
 }
 
 // TextColor returns the color of the text, depending on whether it is highlighted.
 func (h *TermTextGridStyle) TextColor() color.Color {
+	if h.Attributes&AttributeHidden != 0 {
+		if bg := h.BackgroundColor(); bg != nil {
+			return bg
+		}
+		return color.Transparent
+	}
+	if h.Attributes&AttributeDim != 0 {
+		fg, bg := h.textColor(), h.BackgroundColor()
+		if fg == nil {
+			fg = h.defaultColor(theme.ColorNameForeground)
+		}
+		if bg == nil {
+			bg = h.defaultColor(theme.ColorNameBackground)
+		}
+		return dimColor(fg, bg)
+	}
+	return h.textColor()
+}
+
+func (h *TermTextGridStyle) textColor() color.Color {
 	if h.Highlighted {
-		if h.blinked {
+		if h.blinked != h.inverse() {
 			return h.InvertedBackgroundColor
 		}
 		return h.InvertedTextColor
 	}
 	if h.blinked {
-		if h.OriginalBackgroundColor == nil {
-			return color.Transparent
+		if bg := h.background(); bg != nil {
+			return bg
 		}
-		return h.OriginalBackgroundColor
+		return color.Transparent
 	}
-	return h.OriginalTextColor
+	return h.foreground()
 }
 
 // BackgroundColor returns the background color, depending on whether it is highlighted.
 func (h *TermTextGridStyle) BackgroundColor() color.Color {
 	if h.Highlighted {
+		if h.inverse() {
+			return h.InvertedTextColor
+		}
 		return h.InvertedBackgroundColor
 	}
+	return h.background()
+}
+
+// foreground returns the colour the text would be drawn in, taking reverse video into account.
+// The theme is looked up at the time of drawing so inverted default colours follow theme changes.
+func (h *TermTextGridStyle) foreground() color.Color {
+	if !h.inverse() {
+		return h.OriginalTextColor
+	}
+	if h.OriginalBackgroundColor == nil {
+		return h.defaultColor(theme.ColorNameBackground)
+	}
 	return h.OriginalBackgroundColor
 }
 
+// background returns the colour behind the text, taking reverse video into account.
+func (h *TermTextGridStyle) background() color.Color {
+	if !h.inverse() {
+		return h.OriginalBackgroundColor
+	}
+	if h.OriginalTextColor == nil {
+		return h.defaultColor(theme.ColorNameForeground)
+	}
+	return h.OriginalTextColor
+}
+
+// defaultColor returns the theme colour used for text or background without an explicit colour.
+// It is looked up in the grid's theme, so it matches the cells that are drawn without a style.
+func (h *TermTextGridStyle) defaultColor(name fyne.ThemeColorName) color.Color {
+	if h.theme == nil {
+		return theme.Color(name)
+	}
+	return h.theme.Color(name, fyne.CurrentApp().Settings().ThemeVariant())
+}
+
+func (h *TermTextGridStyle) inverse() bool {
+	return h.Attributes&AttributeInverse != 0
+}
+
 func (h *TermTextGridStyle) blink(b bool) {
 	h.blinked = b
 }
@@ -252,6 +476,18 @@ func NewTermTextGridStyle(fg, bg color.Color, bitmask byte, blinkEnabled bool) w
 	}
 }
 
+// dimColor returns a colour half way between the text colour fg and the background bg.
+func dimColor(fg, bg color.Color) color.Color {
+	fr, fgG, fb, fa := fg.RGBA()
+	br, bgG, bb, _ := bg.RGBA()
+	return color.RGBA{
+		R: uint8((fr + br) >> 9),
+		G: uint8((fgG + bgG) >> 9),
+		B: uint8((fb + bb) >> 9),
+		A: uint8(fa >> 8),
+	}
+}
+
 // invertColor inverts a color c with the given bitmask
 func invertColor(c color.Color, bitmask uint8) color.Color {
 	r, g, b, a := c.RGBA()
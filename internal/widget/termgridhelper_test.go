@@ -1,8 +1,10 @@
 package widget
 
 import (
+	"image/color"
 	"testing"
 
+	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/test"
 	"fyne.io/fyne/v2/widget"
 )
@@ -129,3 +131,102 @@ func TestGetTextRange(t *testing.T) {
 		})
 	}
 }
+
+func TestGetTextRange_Wrapped(t *testing.T) {
+	// start the test app for the purpose of the test
+	test.NewApp()
+	textGrid := NewTermGrid()
+	textGrid.Rows = []widget.TextGridRow{
+		{Cells: []widget.TextGridCell{{Rune: 'A'}, {Rune: 'B'}, {Rune: 'C'}}},
+		{Cells: []widget.TextGridCell{{Rune: 'D'}, {Rune: 'E'}, {Rune: 'F'}}},
+		{Cells: []widget.TextGridCell{{Rune: 'G'}, {Rune: 'H'}, {Rune: 'I'}}},
+	}
+	textGrid.SetRowInfo(0, RowInfo{Wrapped: true})
+
+	tests := map[string]struct {
+		startRow  int
+		startCol  int
+		endRow    int
+		endCol    int
+		blockMode bool
+		want      string
+	}{
+		"Full grid":  {0, 0, 2, 2, false, "ABCDEF\nGHI"},
+		"Sub grid":   {0, 1, 1, 1, false, "BCDE"},
+		"Block mode": {0, 1, 2, 2, true, "BC\nEF\nHI"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := GetTextRange(textGrid, tc.blockMode, tc.startRow, tc.startCol, tc.endRow, tc.endCol)
+			if got != tc.want {
+				t.Fatalf("GetTextRange() = %v; want %v", got, tc.want)
+			}
+		})
+	}
+
+	if got := textGrid.Text(); got != "ABCDEF\nGHI" {
+		t.Fatalf("Text() = %v; want %v", got, "ABCDEF\nGHI")
+	}
+	textGrid.SetRowInfo(0, RowInfo{})
+	if got := textGrid.Text(); got != "ABC\nDEF\nGHI" {
+		t.Fatalf("Text() = %v; want %v", got, "ABC\nDEF\nGHI")
+	}
+}
+
+func TestTermTextGridStyle_Attributes(t *testing.T) {
+	fg := color.RGBA{R: 200, G: 100, B: 0, A: 255}
+	bg := color.RGBA{R: 0, G: 0, B: 100, A: 255}
+	style := NewTermTextGridStyle(fg, bg, 0xAA, false).(*TermTextGridStyle)
+	if got := style.TextColor(); got != fg {
+		t.Errorf("TextColor() = %v; want %v", got, fg)
+	}
+
+	style.Attributes = AttributeDim
+	if got, want := style.TextColor(), (color.RGBA{R: 100, G: 50, B: 50, A: 255}); got != want {
+		t.Errorf("dim TextColor() = %v; want %v", got, want)
+	}
+
+	style.Attributes = AttributeHidden
+	if got := style.TextColor(); got != bg {
+		t.Errorf("hidden TextColor() = %v; want %v", got, bg)
+	}
+
+	style.Attributes = AttributeBold | AttributeItalic
+	if got, want := style.Style(), (fyne.TextStyle{Bold: true, Italic: true}); got != want {
+		t.Errorf("Style() = %v; want %v", got, want)
+	}
+}
+
+func TestReplaceColors(t *testing.T) {
+	test.NewApp()
+	old := &color.RGBA{R: 170, A: 255}
+	same := &color.RGBA{R: 170, A: 255}
+	c := &color.RGBA{G: 170, A: 255}
+	term := NewTermTextGridStyle(old, same, 0xAA, false).(*TermTextGridStyle)
+	rows := []widget.TextGridRow{
+		{Cells: []widget.TextGridCell{
+			{Rune: 'A', Style: &widget.CustomTextGridStyle{FGColor: old, BGColor: same}},
+			{Rune: 'B', Style: term},
+			{Rune: 'C'},
+			{Rune: 'D', Style: &widget.CustomTextGridStyle{FGColor: c}},
+		}},
+	}
+
+	ReplaceColors(rows, map[color.Color]color.Color{old: c, c: old}, 0xAA)
+	if fg := rows[0].Cells[0].Style.TextColor(); fg != c {
+		t.Errorf("text colour = %v; want %v", fg, c)
+	}
+	if bg := rows[0].Cells[0].Style.BackgroundColor(); bg != same {
+		t.Errorf("background colour = %v; want the unchanged %v", bg, same)
+	}
+	if term.OriginalTextColor != c || term.InvertedTextColor != invertColor(c, 0xAA) {
+		t.Errorf("text colours = %v, %v; want %v and its inverse", term.OriginalTextColor, term.InvertedTextColor, c)
+	}
+	if term.OriginalBackgroundColor != same {
+		t.Errorf("background colour = %v; want the unchanged %v", term.OriginalBackgroundColor, same)
+	}
+	if fg := rows[0].Cells[3].Style.TextColor(); fg != old {
+		t.Errorf("swapped text colour = %v; want %v", fg, old)
+	}
+}
@@ -0,0 +1,52 @@
+package terminal
+
+import (
+	"runtime"
+
+	"fyne.io/fyne/v2"
+)
+
+// keypadKeys maps the scan codes of the numeric keypad to the final character of the SS3 sequence
+// sent in application keypad mode (DECKPAM). Fyne gives keypad keys the same names as the main keyboard
+// so the scan code, which depends on the platform, is the only way to tell them apart.
+var keypadKeys = keypadScanCodes(runtime.GOOS)
+
+func keypadScanCodes(os string) map[int]byte {
+	switch os {
+	case "darwin":
+		return map[int]byte{
+			0x52: 'p', 0x53: 'q', 0x54: 'r', 0x55: 's', 0x56: 't',
+			0x57: 'u', 0x58: 'v', 0x59: 'w', 0x5b: 'x', 0x5c: 'y',
+			0x41: 'n', 0x43: 'j', 0x45: 'k', 0x4b: 'o', 0x4e: 'm', 0x51: 'X',
+		}
+	case "windows":
+		return map[int]byte{
+			0x52: 'p', 0x4f: 'q', 0x50: 'r', 0x51: 's', 0x4b: 't',
+			0x4c: 'u', 0x4d: 'v', 0x47: 'w', 0x48: 'x', 0x49: 'y',
+			0x53: 'n', 0x37: 'j', 0x4e: 'k', 0x135: 'o', 0x4a: 'm',
+		}
+	default: // X11 key codes
+		return map[int]byte{
+			90: 'p', 87: 'q', 88: 'r', 89: 's', 83: 't',
+			84: 'u', 85: 'v', 79: 'w', 80: 'x', 81: 'y',
+			91: 'n', 63: 'j', 86: 'k', 106: 'o', 82: 'm', 125: 'X',
+		}
+	}
+}
+
+// typeKeypadKey sends the application keypad sequence if the key event came from the numeric keypad.
+// The character that the key goes on to type is dropped by TypedRune.
+func (t *Terminal) typeKeypadKey(e *fyne.KeyEvent) bool {
+	if e.Name == fyne.KeyEnter { // only the keypad Enter key has this name, the main one is KeyReturn
+		_, _ = t.in.Write([]byte{asciiEscape, 'O', 'M'})
+		return true
+	}
+
+	final, ok := keypadKeys[e.Physical.ScanCode]
+	if !ok {
+		return false
+	}
+	_, _ = t.in.Write([]byte{asciiEscape, 'O', final})
+	t.keyboardState.runeSent = true
+	return true
+}
@@ -0,0 +1,219 @@
+package terminal
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"fyne.io/fyne/v2"
+)
+
+// functionKey describes the sequence sent by a function or navigation key.
+// Keys with a number are sent as CSI number ~, the others as CSI final or SS3 final.
+type functionKey struct {
+	number int
+	final  byte
+	ss3    bool // unmodified key is sent as SS3 final, like F1 to F4
+	cursor bool // unmodified key is sent as SS3 final in application cursor key mode
+}
+
+var functionKeys = map[fyne.KeyName]functionKey{
+	fyne.KeyF1:       {final: 'P', ss3: true},
+	fyne.KeyF2:       {final: 'Q', ss3: true},
+	fyne.KeyF3:       {final: 'R', ss3: true},
+	fyne.KeyF4:       {final: 'S', ss3: true},
+	fyne.KeyF5:       {number: 15},
+	fyne.KeyF6:       {number: 17},
+	fyne.KeyF7:       {number: 18},
+	fyne.KeyF8:       {number: 19},
+	fyne.KeyF9:       {number: 20},
+	fyne.KeyF10:      {number: 21},
+	fyne.KeyF11:      {number: 23},
+	fyne.KeyF12:      {number: 24},
+	fyne.KeyInsert:   {number: 2},
+	fyne.KeyDelete:   {number: 3},
+	fyne.KeyPageUp:   {number: 5},
+	fyne.KeyPageDown: {number: 6},
+	fyne.KeyUp:       {final: 'A', cursor: true},
+	fyne.KeyDown:     {final: 'B', cursor: true},
+	fyne.KeyRight:    {final: 'C', cursor: true},
+	fyne.KeyLeft:     {final: 'D', cursor: true},
+	fyne.KeyHome:     {final: 'H', cursor: true},
+	fyne.KeyEnd:      {final: 'F', cursor: true},
+}
+
+// controlKeys are the keys that send a single control character when pressed without modifiers.
+// KeyEnter, from the keypad, is not listed as it depends on new line mode.
+var controlKeys = map[fyne.KeyName]byte{
+	fyne.KeyReturn:    '\r',
+	fyne.KeyTab:       '\t',
+	fyne.KeyEscape:    asciiEscape,
+	fyne.KeyBackspace: asciiBackspace,
+}
+
+// sequence returns the bytes sent for the key with the given xterm modifier parameter, where 1 means none.
+func (k functionKey) sequence(modifier int, appCursorKeys bool) []byte {
+	switch {
+	case modifier > 1 && k.number > 0:
+		return []byte(fmt.Sprintf("%c[%d;%d~", asciiEscape, k.number, modifier))
+	case modifier > 1:
+		return []byte(fmt.Sprintf("%c[1;%d%c", asciiEscape, modifier, k.final))
+	case k.number > 0:
+		return []byte(fmt.Sprintf("%c[%d~", asciiEscape, k.number))
+	case k.ss3 || (k.cursor && appCursorKeys):
+		return []byte{asciiEscape, 'O', k.final}
+	}
+	return []byte{asciiEscape, '[', k.final}
+}
+
+// SetAltSendsEscape sets whether holding Alt sends characters prefixed with Escape, as used for Meta by many programs.
+// It is off by default as it stops Alt (Option on macOS) from typing alternative characters.
+func (t *Terminal) SetAltSendsEscape(alt bool) {
+	t.altSendsEscape = alt
+}
+
+// keyboardModifiers returns the modifier keys currently held down.
+func (t *Terminal) keyboardModifiers() fyne.KeyModifier {
+	var mods fyne.KeyModifier
+	if t.keyboardState.shiftPressed {
+		mods |= fyne.KeyModifierShift
+	}
+	if t.keyboardState.altPressed {
+		mods |= fyne.KeyModifierAlt
+	}
+	if t.keyboardState.ctrlPressed {
+		mods |= fyne.KeyModifierControl
+	}
+	if t.keyboardState.superPressed {
+		mods |= fyne.KeyModifierSuper
+	}
+	return mods
+}
+
+// typeKey sends the sequence for a non-printable key pressed with the given modifiers.
+// It returns false if the key is not one that we send.
+func (t *Terminal) typeKey(key fyne.KeyName, mods fyne.KeyModifier) bool {
+	modifier := keyModifierParam(mods)
+	if k, ok := functionKeys[key]; ok {
+		_, _ = t.in.Write(k.sequence(modifier, t.appCursorKeys))
+		return true
+	}
+
+	c, ok := controlKeys[key]
+	if key == fyne.KeyEnter {
+		c, ok = '\r', true
+	}
+	if !ok {
+		return false
+	}
+
+	switch {
+	case key == fyne.KeyTab && mods == fyne.KeyModifierShift: // back tab, even with modifyOtherKeys as in xterm
+		_, _ = t.in.Write([]byte{asciiEscape, '[', 'Z'})
+	case modifier > 1 && t.modifyOtherKeys > 0:
+		_, _ = fmt.Fprintf(t.in, "%c[27;%d;%d~", asciiEscape, modifier, c)
+	case key == fyne.KeyEnter && !t.newLineMode:
+		_, _ = t.in.Write([]byte{'\n'})
+	case mods&fyne.KeyModifierAlt != 0 && t.altSendsEscape:
+		_, _ = t.in.Write([]byte{asciiEscape, c})
+	default:
+		_, _ = t.in.Write([]byte{c})
+	}
+	return true
+}
+
+// typeModifiedRune sends a printable key pressed with Control or Alt.
+// It returns false if the combination does not send anything.
+func (t *Terminal) typeModifiedRune(key fyne.KeyName, mods fyne.KeyModifier) bool {
+	r := keyRune(key, mods&fyne.KeyModifierShift != 0)
+	if r == 0 || mods&fyne.KeyModifierSuper != 0 {
+		return false
+	}
+	if mods&(fyne.KeyModifierControl|fyne.KeyModifierShift) == fyne.KeyModifierControl|fyne.KeyModifierShift {
+		return false // reserved for terminal shortcuts such as copy and paste
+	}
+	if t.modifyOtherKeys >= 2 {
+		_, _ = fmt.Fprintf(t.in, "%c[27;%d;%d~", asciiEscape, keyModifierParam(mods), r)
+		return true
+	}
+
+	b := []byte(string(r))
+	if mods&fyne.KeyModifierControl != 0 {
+		c, ok := controlChar(key)
+		if !ok {
+			return false
+		}
+		b = []byte{c}
+	}
+	if mods&fyne.KeyModifierAlt != 0 {
+		if !t.altSendsEscape {
+			return false
+		}
+		b = append([]byte{asciiEscape}, b...)
+	}
+	_, _ = t.in.Write(b)
+	return true
+}
+
+// handleModifyKeys handles the xterm key modifier options, set by CSI > resource ; value m.
+func (t *Terminal) handleModifyKeys(msg string) {
+	params := strings.Split(msg, ";")
+	if params[0] != "4" {
+		if t.debug {
+			log.Println("Unsupported key modifier option", msg)
+		}
+		return
+	}
+
+	level := 0
+	if len(params) > 1 {
+		level, _ = strconv.Atoi(params[1])
+	}
+	t.modifyOtherKeys = level
+}
+
+// controlChar returns the control character typed by pressing Control with the named key, from Ctrl+@ to Ctrl+_.
+func controlChar(key fyne.KeyName) (byte, bool) {
+	if key == fyne.KeySpace || key == "@" {
+		return 0, true
+	}
+	if len(key) != 1 || key[0] < 'A' || key[0] > '_' {
+		return 0, false
+	}
+	return key[0] - 'A' + 1, true
+}
+
+// keyModifierParam returns the xterm modifier parameter for the modifier keys held, 1 means none.
+func keyModifierParam(m fyne.KeyModifier) int {
+	param := 1
+	if m&fyne.KeyModifierShift != 0 {
+		param++
+	}
+	if m&fyne.KeyModifierAlt != 0 {
+		param += 2
+	}
+	if m&fyne.KeyModifierControl != 0 {
+		param += 4
+	}
+	if m&fyne.KeyModifierSuper != 0 {
+		param += 8
+	}
+	return param
+}
+
+// keyRune returns the character typed by a printable key, or 0 if the key is not printable.
+func keyRune(key fyne.KeyName, shift bool) rune {
+	if key == fyne.KeySpace {
+		return ' '
+	}
+	r, size := utf8.DecodeRuneInString(string(key))
+	if size != len(key) {
+		return 0
+	}
+	if !shift && r >= 'A' && r <= 'Z' {
+		r += 'a' - 'A'
+	}
+	return r
+}
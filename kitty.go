@@ -0,0 +1,258 @@
+package terminal
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+)
+
+// The progressive enhancement flags of the kitty keyboard protocol.
+const (
+	kittyDisambiguate = 1 << iota
+	kittyReportEvents
+	kittyReportAlternates
+	kittyReportAllKeys
+	kittyReportText
+)
+
+const maxKittyStack = 16
+
+// kittyEvent is the type of key event reported when kittyReportEvents is set.
+type kittyEvent int
+
+const (
+	kittyPress kittyEvent = iota + 1
+	kittyRepeat
+	kittyRelease
+)
+
+// kittyKey is the code and final character a non-text key is reported with.
+type kittyKey struct {
+	code  int
+	final byte
+}
+
+var kittyKeys = map[fyne.KeyName]kittyKey{
+	fyne.KeyEscape:    {27, 'u'},
+	fyne.KeyReturn:    {13, 'u'},
+	fyne.KeyEnter:     {13, 'u'},
+	fyne.KeyTab:       {9, 'u'},
+	fyne.KeyBackspace: {127, 'u'},
+	fyne.KeyInsert:    {2, '~'},
+	fyne.KeyDelete:    {3, '~'},
+	fyne.KeyLeft:      {1, 'D'},
+	fyne.KeyRight:     {1, 'C'},
+	fyne.KeyUp:        {1, 'A'},
+	fyne.KeyDown:      {1, 'B'},
+	fyne.KeyPageUp:    {5, '~'},
+	fyne.KeyPageDown:  {6, '~'},
+	fyne.KeyHome:      {1, 'H'},
+	fyne.KeyEnd:       {1, 'F'},
+	fyne.KeyF1:        {1, 'P'},
+	fyne.KeyF2:        {1, 'Q'},
+	fyne.KeyF3:        {13, '~'},
+	fyne.KeyF4:        {1, 'S'},
+	fyne.KeyF5:        {15, '~'},
+	fyne.KeyF6:        {17, '~'},
+	fyne.KeyF7:        {18, '~'},
+	fyne.KeyF8:        {19, '~'},
+	fyne.KeyF9:        {20, '~'},
+	fyne.KeyF10:       {21, '~'},
+	fyne.KeyF11:       {23, '~'},
+	fyne.KeyF12:       {24, '~'},
+
+	desktop.KeyShiftLeft:    {57441, 'u'},
+	desktop.KeyControlLeft:  {57442, 'u'},
+	desktop.KeyAltLeft:      {57443, 'u'},
+	desktop.KeySuperLeft:    {57444, 'u'},
+	desktop.KeyShiftRight:   {57447, 'u'},
+	desktop.KeyControlRight: {57448, 'u'},
+	desktop.KeyAltRight:     {57449, 'u'},
+	desktop.KeySuperRight:   {57450, 'u'},
+}
+
+// escapeKeyboardProtocol handles the kitty keyboard protocol requests, all of which share the final
+// character with restoring the cursor, which is what an unprefixed request does.
+func escapeKeyboardProtocol(t *Terminal, msg string) {
+	if msg == "" {
+		escapeRestoreCursor(t, msg)
+		return
+	}
+
+	params := strings.Split(msg[1:], ";")
+	value, _ := strconv.Atoi(params[0])
+	switch msg[0] {
+	case '>':
+		t.pushKittyFlags(value)
+	case '<':
+		if value == 0 {
+			value = 1
+		}
+		t.popKittyFlags(value)
+	case '=':
+		mode := 1
+		if len(params) > 1 {
+			mode, _ = strconv.Atoi(params[1])
+		}
+		t.setKittyFlags(value, mode)
+	case '?':
+		t.reply("%c[?%du", asciiEscape, t.kittyFlags())
+	default:
+		if t.debug {
+			log.Println("Unrecognised Escape:", msg+"u")
+		}
+	}
+}
+
+// kittyFlags returns the current kitty keyboard protocol flags, 0 means legacy key handling.
+func (t *Terminal) kittyFlags() int {
+	if len(t.kittyStack) == 0 {
+		return 0
+	}
+	return t.kittyStack[len(t.kittyStack)-1]
+}
+
+func (t *Terminal) pushKittyFlags(flags int) {
+	if len(t.kittyStack) >= maxKittyStack {
+		t.kittyStack = t.kittyStack[1:] // the oldest entry is dropped to make space
+	}
+	t.kittyStack = append(t.kittyStack, flags)
+}
+
+func (t *Terminal) popKittyFlags(count int) {
+	if count >= len(t.kittyStack) {
+		t.kittyStack = nil
+		return
+	}
+	t.kittyStack = t.kittyStack[:len(t.kittyStack)-count]
+}
+
+// setKittyFlags changes the current flags, mode 1 replaces them, 2 adds to them and 3 removes from them.
+func (t *Terminal) setKittyFlags(flags, mode int) {
+	current := t.kittyFlags()
+	switch mode {
+	case 1:
+		current = flags
+	case 2:
+		current |= flags
+	case 3:
+		current &^= flags
+	default:
+		return
+	}
+
+	if len(t.kittyStack) == 0 {
+		t.kittyStack = []int{current}
+		return
+	}
+	t.kittyStack[len(t.kittyStack)-1] = current
+}
+
+// typeKittyKey reports a key press or repeat using the kitty keyboard protocol.
+// It returns false if the key should be sent as it would be without the protocol, such as text typed without
+// modifiers when all keys are not being reported.
+func (t *Terminal) typeKittyKey(key fyne.KeyName, mods fyne.KeyModifier) bool {
+	flags := t.kittyFlags()
+	event := kittyRepeat
+	if t.keyboardState.lastDown == key {
+		t.keyboardState.lastDown = ""
+		event = kittyPress
+	}
+
+	if flags&kittyReportAllKeys == 0 {
+		switch key {
+		case fyne.KeyReturn, fyne.KeyEnter, fyne.KeyTab, fyne.KeyBackspace:
+			if mods == 0 {
+				return false
+			}
+		}
+		if _, ok := kittyKeys[key]; !ok && mods&^fyne.KeyModifierShift == 0 {
+			return false // typed as text
+		}
+	}
+
+	if !t.sendKittyKey(key, mods, event) {
+		return false
+	}
+	if _, ok := kittyKeys[key]; !ok {
+		t.keyboardState.runeSent = true // whatever the key types, which depends on the layout, was reported
+	}
+	return true
+}
+
+// releaseKittyKey reports a key being released, if the current flags ask for it.
+func (t *Terminal) releaseKittyKey(key fyne.KeyName) {
+	flags := t.kittyFlags()
+	if flags&kittyReportEvents == 0 {
+		return
+	}
+	if flags&kittyReportAllKeys == 0 {
+		switch key {
+		case fyne.KeyReturn, fyne.KeyEnter, fyne.KeyTab, fyne.KeyBackspace:
+			return
+		}
+		if isModifierKey(key) {
+			return
+		}
+	}
+
+	t.sendKittyKey(key, t.keyboardModifiers(), kittyRelease)
+}
+
+// sendKittyKey writes the CSI sequence for a key event, returning false if the key has no code.
+func (t *Terminal) sendKittyKey(key fyne.KeyName, mods fyne.KeyModifier, event kittyEvent) bool {
+	flags := t.kittyFlags()
+	k, ok := kittyKeys[key]
+	var text rune
+	if !ok {
+		r := keyRune(key, false)
+		if r == 0 {
+			return false
+		}
+		k = kittyKey{code: int(r), final: 'u'}
+		if mods&^fyne.KeyModifierShift == 0 {
+			text = keyRune(key, mods&fyne.KeyModifierShift != 0)
+		}
+	}
+
+	code := strconv.Itoa(k.code)
+	if flags&kittyReportAlternates != 0 && mods&fyne.KeyModifierShift != 0 {
+		if shifted := keyRune(key, true); shifted != 0 && int(shifted) != k.code {
+			code += ":" + strconv.Itoa(int(shifted))
+		}
+	}
+
+	modifier := keyModifierParam(mods)
+	params := ""
+	if modifier > 1 || (event != kittyPress && flags&kittyReportEvents != 0) {
+		params = ";" + strconv.Itoa(modifier)
+		if event != kittyPress && flags&kittyReportEvents != 0 {
+			params += ":" + strconv.Itoa(int(event))
+		}
+	}
+	if text != 0 && flags&kittyReportText != 0 && event != kittyRelease {
+		if params == "" {
+			params = ";1"
+		}
+		params += ";" + strconv.Itoa(int(text))
+	}
+
+	if k.code == 1 && k.final != 'u' && params == "" {
+		code = "" // unmodified cursor keys and F1 to F4 have no number
+	}
+	_, _ = fmt.Fprintf(t.in, "%c[%s%s%c", asciiEscape, code, params, k.final)
+	return true
+}
+
+func isModifierKey(key fyne.KeyName) bool {
+	switch key {
+	case desktop.KeyShiftLeft, desktop.KeyShiftRight, desktop.KeyControlLeft, desktop.KeyControlRight,
+		desktop.KeyAltLeft, desktop.KeyAltRight, desktop.KeySuperLeft, desktop.KeySuperRight:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package terminal
+
+import (
+	"bytes"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKittyKeyboard_Flags(t *testing.T) {
+	inBuffer := bytes.NewBuffer([]byte{})
+	term := New()
+	term.in = NopCloser(inBuffer)
+	term.config.Columns = 5
+	term.config.Rows = 2
+	term.Refresh() // ensure visuals set up
+
+	term.handleOutput([]byte(esc("[?u")))
+	assert.Equal(t, esc("[?0u"), inBuffer.String())
+
+	term.handleOutput([]byte(esc("[>1u") + esc("[>3u") + esc("[=8;2u")))
+	assert.Equal(t, 11, term.kittyFlags())
+	term.handleOutput([]byte(esc("[=2;3u")))
+	assert.Equal(t, 9, term.kittyFlags())
+
+	term.handleOutput([]byte(esc("[?1049h")))
+	assert.Equal(t, 0, term.kittyFlags())
+	term.handleOutput([]byte(esc("[>5u")))
+	term.handleOutput([]byte(esc("[?1049l")))
+	assert.Equal(t, 9, term.kittyFlags())
+
+	term.handleOutput([]byte(esc("[<u")))
+	assert.Equal(t, 1, term.kittyFlags())
+	term.handleOutput([]byte(esc("[<5u")))
+	assert.Equal(t, 0, term.kittyFlags())
+
+	term.cursorRow, term.cursorCol = 1, 3
+	term.savedRow, term.savedCol = 0, 1
+	term.handleOutput([]byte(esc("[u")))
+	assert.Equal(t, 0, term.cursorRow)
+	assert.Equal(t, 1, term.cursorCol)
+}
+
+func TestKittyKeyboard_Keys(t *testing.T) {
+	type key struct {
+		name fyne.KeyName
+		mods fyne.KeyModifier
+	}
+	tests := map[string]struct {
+		flags int
+		key   key
+		want  string
+	}{
+		"escape":              {flags: 1, key: key{fyne.KeyEscape, 0}, want: esc("[27u")},
+		"enter":               {flags: 1, key: key{fyne.KeyReturn, 0}, want: "\r"},
+		"control enter":       {flags: 1, key: key{fyne.KeyReturn, fyne.KeyModifierControl}, want: esc("[13;5u")},
+		"text":                {flags: 1, key: key{fyne.KeyA, 0}, want: ""},
+		"control text":        {flags: 1, key: key{fyne.KeyA, fyne.KeyModifierControl}, want: esc("[97;5u")},
+		"super text":          {flags: 1, key: key{fyne.KeyA, fyne.KeyModifierSuper}, want: esc("[97;9u")},
+		"up":                  {flags: 1, key: key{fyne.KeyUp, 0}, want: esc("[A")},
+		"shift up":            {flags: 1, key: key{fyne.KeyUp, fyne.KeyModifierShift}, want: esc("[1;2A")},
+		"F3":                  {flags: 1, key: key{fyne.KeyF3, 0}, want: esc("[13~")},
+		"all keys text":       {flags: 8, key: key{fyne.KeyA, 0}, want: esc("[97u")},
+		"all keys enter":      {flags: 8, key: key{fyne.KeyReturn, 0}, want: esc("[13u")},
+		"alternate shift":     {flags: 12, key: key{fyne.KeyA, fyne.KeyModifierShift}, want: esc("[97:65;2u")},
+		"text as code points": {flags: 24, key: key{fyne.KeyA, fyne.KeyModifierShift}, want: esc("[97;2;65u")},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			inBuffer := bytes.NewBuffer([]byte{})
+			term := &Terminal{in: NopCloser(inBuffer)}
+			term.pushKittyFlags(tt.flags)
+
+			term.KeyDown(&fyne.KeyEvent{Name: tt.key.name})
+			if tt.key.mods == 0 {
+				term.TypedKey(&fyne.KeyEvent{Name: tt.key.name})
+			} else {
+				term.TypedShortcut(&desktop.CustomShortcut{KeyName: tt.key.name, Modifier: tt.key.mods})
+			}
+			assert.Equal(t, tt.want, inBuffer.String())
+		})
+	}
+}
+
+func TestKittyKeyboard_Events(t *testing.T) {
+	inBuffer := bytes.NewBuffer([]byte{})
+	term := &Terminal{in: NopCloser(inBuffer)}
+	term.pushKittyFlags(kittyDisambiguate | kittyReportEvents)
+
+	term.KeyDown(&fyne.KeyEvent{Name: fyne.KeyF5})
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.KeyF5})
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.KeyF5})
+	term.KeyUp(&fyne.KeyEvent{Name: fyne.KeyF5})
+	assert.Equal(t, esc("[15~")+esc("[15;1:2~")+esc("[15;1:3~"), inBuffer.String())
+
+	inBuffer.Reset()
+	term.KeyDown(&fyne.KeyEvent{Name: fyne.KeyA})
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.KeyA})
+	term.TypedRune('a')
+	term.KeyUp(&fyne.KeyEvent{Name: fyne.KeyA})
+	term.KeyDown(&fyne.KeyEvent{Name: fyne.KeyReturn})
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+	term.KeyUp(&fyne.KeyEvent{Name: fyne.KeyReturn})
+	assert.Equal(t, "a"+esc("[97;1:3u")+"\r", inBuffer.String())
+
+	inBuffer.Reset()
+	term.pushKittyFlags(kittyReportEvents | kittyReportAllKeys)
+	term.KeyDown(&fyne.KeyEvent{Name: desktop.KeyShiftLeft})
+	term.TypedKey(&fyne.KeyEvent{Name: desktop.KeyShiftLeft})
+	term.KeyDown(&fyne.KeyEvent{Name: fyne.KeyA})
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.KeyA})
+	term.TypedRune('A')
+	term.KeyUp(&fyne.KeyEvent{Name: desktop.KeyShiftLeft})
+	assert.Equal(t, esc("[57441;2u")+esc("[97;2u")+esc("[57441;1:3u"), inBuffer.String())
+
+	inBuffer.Reset()
+	term.keyboardState.shiftPressed = true
+	term.KeyDown(&fyne.KeyEvent{Name: fyne.Key1})
+	term.TypedKey(&fyne.KeyEvent{Name: fyne.Key1})
+	term.TypedRune('!') // the shifted character is not known from the key name
+	assert.Equal(t, esc("[49;2u"), inBuffer.String())
+}
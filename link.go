@@ -0,0 +1,121 @@
+package terminal
+
+import (
+	"log"
+	"net/url"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	widget2 "github.com/wangyiyang/Magic-Terminal/internal/widget"
+)
+
+// maxHyperlinkIDs limits how many link IDs we remember, the oldest links can no longer be joined once it is reached.
+const maxHyperlinkIDs = 1024
+
+// defaultLinkSchemes are the kinds of link that are opened if OnLinkActivated is not set.
+// Links come from untrusted output, so file links and custom protocol handlers are not opened.
+var defaultLinkSchemes = map[string]bool{"http": true, "https": true, "mailto": true}
+
+// setHyperlink handles OSC 8, which starts a hyperlink with the format `params;URI`, or ends it if the URI is empty.
+// Cells printed with the same `id=` parameter and URI are one link, even if they are not next to each other.
+func (t *Terminal) setHyperlink(code string) {
+	params, uri, _ := strings.Cut(code, ";")
+	if uri == "" {
+		t.hyperlink = nil
+		return
+	}
+
+	id := ""
+	for _, param := range strings.Split(params, ":") {
+		if value, ok := strings.CutPrefix(param, "id="); ok {
+			id = value
+		}
+	}
+	if id == "" {
+		t.hyperlink = &widget2.Hyperlink{URI: uri}
+		return
+	}
+
+	key := id + ";" + uri
+	if link, ok := t.hyperlinks[key]; ok {
+		t.hyperlink = link
+		return
+	}
+	if t.hyperlinks == nil || len(t.hyperlinks) >= maxHyperlinkIDs {
+		t.hyperlinks = make(map[string]*widget2.Hyperlink)
+	}
+	t.hyperlink = &widget2.Hyperlink{ID: id, URI: uri}
+	t.hyperlinks[key] = t.hyperlink
+}
+
+// linkAt returns the hyperlink displayed at the given position, or nil if there is none.
+func (t *Terminal) linkAt(pos fyne.Position) *widget2.Hyperlink {
+	grid := t.content
+	if t.scrollOffset > 0 {
+		grid = t.history
+	}
+	if grid == nil {
+		return nil
+	}
+
+	p := t.getTermPosition(pos)
+	if p.Row < 1 || p.Row > len(grid.Rows) {
+		return nil
+	}
+	cells := grid.Rows[p.Row-1].Cells
+	if p.Col < 1 || p.Col > len(cells) {
+		return nil
+	}
+	if s, ok := cells[p.Col-1].Style.(*widget2.TermTextGridStyle); ok {
+		return s.Link
+	}
+	return nil
+}
+
+// hoverLink underlines the link under the mouse, removing the underline from the one it was over before.
+func (t *Terminal) hoverLink(link *widget2.Hyperlink) {
+	if link == t.hoveredLink {
+		return
+	}
+
+	if t.hoveredLink != nil {
+		t.hoveredLink.Hovered = false
+	}
+	t.hoveredLink = link
+	if link != nil {
+		link.Hovered = true
+		t.mouseCursor = desktop.PointerCursor
+	} else if t.mouseCursor == desktop.PointerCursor {
+		t.mouseCursor = desktop.DefaultCursor
+	}
+
+	if t.content != nil {
+		t.content.Refresh()
+		t.history.Refresh()
+	}
+}
+
+// activateLink passes the URI of a clicked link to OnLinkActivated, or opens it if that is not set.
+// Only web and mail links are opened by default.
+func (t *Terminal) activateLink(uri string) {
+	if t.OnLinkActivated != nil {
+		t.OnLinkActivated(uri)
+		return
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		fyne.LogError("Failed to parse hyperlink: "+uri, err)
+		return
+	}
+	if !defaultLinkSchemes[u.Scheme] {
+		if t.debug {
+			log.Println("Not opening hyperlink with scheme", u.Scheme)
+		}
+		return
+	}
+	if err = fyne.CurrentApp().OpenURL(u); err != nil {
+		fyne.LogError("Failed to open hyperlink: "+uri, err)
+	}
+}
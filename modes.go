@@ -0,0 +1,99 @@
+package terminal
+
+// privateMode is a DEC private mode, set with CSI ? n h, reset with CSI ? n l and queried with DECRQM.
+type privateMode struct {
+	get func(t *Terminal) bool
+	set func(t *Terminal, enable bool)
+}
+
+// privateModes is the registry of the private modes that we support, by mode number.
+var privateModes = map[int]privateMode{
+	1: {
+		get: func(t *Terminal) bool { return t.appCursorKeys },
+		set: func(t *Terminal, enable bool) { t.appCursorKeys = enable },
+	},
+	5: {
+		get: func(t *Terminal) bool { return t.screenReversed },
+		set: (*Terminal).setScreenReversed,
+	},
+	7: {
+		get: func(t *Terminal) bool { return !t.wrapDisabled },
+		set: func(t *Terminal, enable bool) { t.wrapDisabled = !enable },
+	},
+	9: mouseTrackingMode(9),
+	20: {
+		get: func(t *Terminal) bool { return t.newLineMode },
+		set: func(t *Terminal, enable bool) { t.newLineMode = enable },
+	},
+	25: {
+		get: func(t *Terminal) bool { return !t.cursorHidden },
+		set: func(t *Terminal, enable bool) {
+			t.cursorHidden = !enable
+			t.refreshCursor()
+		},
+	},
+	47: {
+		get: func(t *Terminal) bool { return t.altScreen },
+		set: func(t *Terminal, enable bool) {
+			if enable {
+				t.enterAltScreen(false)
+			} else {
+				t.exitAltScreen(false)
+			}
+		},
+	},
+	1000: mouseTrackingMode(1000),
+	1002: mouseTrackingMode(1002),
+	1003: mouseTrackingMode(1003),
+	1004: {
+		get: func(t *Terminal) bool { return t.focusReporting },
+		set: func(t *Terminal, enable bool) { t.focusReporting = enable },
+	},
+	1005: mouseEncodingMode(1005),
+	1006: mouseEncodingMode(1006),
+	1015: mouseEncodingMode(1015),
+	1047: {
+		get: func(t *Terminal) bool { return t.altScreen },
+		set: func(t *Terminal, enable bool) {
+			if enable {
+				t.enterAltScreen(false)
+			} else {
+				t.exitAltScreen(true)
+			}
+		},
+	},
+	1049: {
+		get: func(t *Terminal) bool { return t.altScreen },
+		set: func(t *Terminal, enable bool) {
+			if enable {
+				escapeSaveCursor(t, "")
+				t.enterAltScreen(true)
+			} else {
+				t.exitAltScreen(true)
+				escapeRestoreCursor(t, "")
+			}
+		},
+	},
+	2004: {
+		get: func(t *Terminal) bool { return t.bracketedPasteMode },
+		set: func(t *Terminal, enable bool) { t.bracketedPasteMode = enable },
+	},
+	2026: {
+		get: func(t *Terminal) bool { return t.syncUpdate },
+		set: (*Terminal).setSyncUpdate,
+	},
+}
+
+func mouseTrackingMode(mode int) privateMode {
+	return privateMode{
+		get: func(t *Terminal) bool { return t.mouseTracking == mode },
+		set: func(t *Terminal, enable bool) { t.setMouseTracking(mode, enable) },
+	}
+}
+
+func mouseEncodingMode(mode int) privateMode {
+	return privateMode{
+		get: func(t *Terminal) bool { return t.mouseEncoding == mode },
+		set: func(t *Terminal, enable bool) { t.setMouseEncoding(mode, enable) },
+	}
+}
@@ -1,9 +1,24 @@
 package terminal
 
 import (
+	"fmt"
+	"unicode/utf8"
+
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+)
+
+// The xterm button codes for mouse reports, before modifiers are added.
+const (
+	mouseRelease   = 3
+	mouseMotion    = 32 // added to the button held while moving, or to mouseRelease if none is
+	mouseWheelUp   = 64
+	mouseWheelDown = 65
 )
 
+// maxLegacyMouseCoord is the largest coordinate that fits in a byte of the legacy mouse encoding.
+const maxLegacyMouseCoord = 255 - 32
+
 func (t *Terminal) handleMouseDownV200(btn int, mods fyne.KeyModifier, pos fyne.Position) {
 	_, _ = t.Write(t.encodeMouse(btn, mods, pos))
 }
@@ -13,31 +28,146 @@ func (t *Terminal) handleMouseDownX10(btn int, _ fyne.KeyModifier, pos fyne.Posi
 }
 
 func (t *Terminal) handleMouseUpV200(btn int, mods fyne.KeyModifier, pos fyne.Position) {
-	_, _ = t.Write(t.encodeMouse(0, mods, pos))
+	_, _ = t.Write(t.encodeMouseEvent(btn-1, true, mods, pos))
 }
 
 func (t *Terminal) handleMouseUpX10(_ int, _ fyne.KeyModifier, _ fyne.Position) {
 	// no-op for X10 mode
 }
 
-func (t *Terminal) encodeMouse(button int, mods fyne.KeyModifier, pos fyne.Position) []byte {
+// setMouseTracking turns on one of the mouse tracking modes (9, 1000, 1002 or 1003) or turns them off.
+func (t *Terminal) setMouseTracking(mode int, enable bool) {
+	t.mouseTracking = 0
+	t.onMouseDown, t.onMouseUp = nil, nil
+	if !enable {
+		return
+	}
+
+	t.mouseTracking = mode
+	if mode == 9 {
+		t.onMouseDown = t.handleMouseDownX10
+		t.onMouseUp = t.handleMouseUpX10
+	} else {
+		t.onMouseDown = t.handleMouseDownV200
+		t.onMouseUp = t.handleMouseUpV200
+	}
+}
+
+// setMouseEncoding selects the extended coordinate encoding (1005, 1006 or 1015) or returns to the legacy one.
+func (t *Terminal) setMouseEncoding(mode int, enable bool) {
+	if enable {
+		t.mouseEncoding = mode
+	} else if t.mouseEncoding == mode {
+		t.mouseEncoding = 0
+	}
+}
+
+// reportingMouse returns true if mouse events should be sent to the application rather than handled locally.
+// Holding Shift bypasses the reporting so that text can still be selected.
+func (t *Terminal) reportingMouse(mods fyne.KeyModifier) bool {
+	return t.onMouseDown != nil && mods&fyne.KeyModifierShift == 0
+}
+
+// reportMouseMotion sends a motion event if the tracking mode asks for it and the mouse moved to another cell.
+// The button is the one held down, or 0 if none are.
+func (t *Terminal) reportMouseMotion(button int, mods fyne.KeyModifier, pos fyne.Position) bool {
+	if t.mouseTracking != 1003 && (t.mouseTracking != 1002 || button == 0) {
+		return false
+	}
+
 	p := t.getTermPosition(pos)
-	var btn byte
+	if t.lastMouseCell == p {
+		return true
+	}
+	t.lastMouseCell = p
+
+	code := mouseRelease
+	if button > 0 {
+		code = button - 1
+	}
+	_, _ = t.Write(t.encodeMouseEvent(code+mouseMotion, false, mods, pos))
+	return true
+}
+
+// reportMouseWheel sends wheel movement as presses of buttons 64 (up) and 65 (down), one for each line.
+func (t *Terminal) reportMouseWheel(lines int, mods fyne.KeyModifier, pos fyne.Position) {
+	code := mouseWheelUp
+	if lines < 0 {
+		code = mouseWheelDown
+		lines = -lines
+	}
+	if t.mouseTracking == 9 {
+		mods = 0
+	}
+
+	for i := 0; i < lines; i++ {
+		_, _ = t.Write(t.encodeMouseEvent(code, false, mods, pos))
+	}
+}
+
+// encodeMouse returns the report for a button being pressed, or released if button is 0.
+// Buttons are numbered from 1, as passed to the mouse handlers.
+func (t *Terminal) encodeMouse(button int, mods fyne.KeyModifier, pos fyne.Position) []byte {
 	if button == 0 {
-		btn = 3
-	} else {
-		btn = byte(button) - 1
+		return t.encodeMouseEvent(mouseRelease, true, mods, pos)
+	}
+	return t.encodeMouseEvent(button-1, false, mods, pos)
+}
+
+// encodeMouseEvent returns the report for a mouse event in the current encoding.
+// The code is the xterm button code, 0 to 2 for buttons 1 to 3, 64 and 65 for the wheel, with 32 added for motion.
+func (t *Terminal) encodeMouseEvent(code int, release bool, mods fyne.KeyModifier, pos fyne.Position) []byte {
+	p := t.getTermPosition(pos)
+	if release && t.mouseEncoding != 1006 {
+		code = mouseRelease // only the SGR encoding says which button was released
 	}
 
 	if mods&fyne.KeyModifierShift != 0 {
-		btn += 4
+		code += 4
 	}
 	if mods&fyne.KeyModifierAlt != 0 {
-		btn += 8
+		code += 8
 	}
 	if mods&fyne.KeyModifierControl != 0 {
-		btn += 16
+		code += 16
+	}
+
+	switch t.mouseEncoding {
+	case 1005: // UTF-8
+		b := []byte{asciiEscape, '[', 'M'}
+		for _, v := range []int{code, p.Col, p.Row} {
+			b = utf8.AppendRune(b, rune(32+v))
+		}
+		return b
+	case 1006: // SGR
+		final := 'M'
+		if release {
+			final = 'm'
+		}
+		return []byte(fmt.Sprintf("%c[<%d;%d;%d%c", asciiEscape, code, p.Col, p.Row, final))
+	case 1015: // urxvt
+		return []byte(fmt.Sprintf("%c[%d;%d;%dM", asciiEscape, 32+code, p.Col, p.Row))
 	}
 
-	return []byte{asciiEscape, '[', 'M', 32 + btn, 32 + byte(p.Col), 32 + byte(p.Row)}
+	col, row := p.Col, p.Row
+	if col > maxLegacyMouseCoord {
+		col = maxLegacyMouseCoord
+	}
+	if row > maxLegacyMouseCoord {
+		row = maxLegacyMouseCoord
+	}
+	return []byte{asciiEscape, '[', 'M', byte(32 + code), byte(32 + col), byte(32 + row)}
+}
+
+// mouseButtonNumber returns the xterm number for a fyne mouse button, 0 if it is not one we report.
+func mouseButtonNumber(b desktop.MouseButton) int {
+	switch {
+	case b&desktop.MouseButtonPrimary != 0:
+		return 1
+	case b&desktop.MouseButtonTertiary != 0:
+		return 2
+	case b&desktop.MouseButtonSecondary != 0:
+		return 3
+	}
+	return 0
 }
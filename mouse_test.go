@@ -1,9 +1,11 @@
 package terminal
 
 import (
+	"bytes"
 	"testing"
 
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -25,3 +27,64 @@ func TestEncodeMouse_Mods(t *testing.T) {
 	assert.Equal(t, "\x1b[M5!!", string(term.encodeMouse(2,
 		fyne.KeyModifierShift|fyne.KeyModifierControl, fyne.NewPos(4, 4))))
 }
+
+func TestEncodeMouse_Encodings(t *testing.T) {
+	term := New()
+	far := fyne.NewPos(term.guessCellSize().Width*299.5, 4)
+
+	term.handleOutput([]byte(esc("[?1006h")))
+	assert.Equal(t, "\x1b[<0;1;1M", string(term.encodeMouse(1, 0, fyne.NewPos(4, 4))))
+	assert.Equal(t, "\x1b[<2;1;1m", string(term.encodeMouseEvent(2, true, 0, fyne.NewPos(4, 4))))
+	assert.Equal(t, "\x1b[<4;300;1M", string(term.encodeMouse(1, fyne.KeyModifierShift, far)))
+
+	term.handleOutput([]byte(esc("[?1015h")))
+	assert.Equal(t, "\x1b[32;300;1M", string(term.encodeMouse(1, 0, far)))
+	assert.Equal(t, "\x1b[35;1;1M", string(term.encodeMouse(0, 0, fyne.NewPos(4, 4))))
+
+	term.handleOutput([]byte(esc("[?1005h")))
+	assert.Equal(t, "\x1b[M Ō!", string(term.encodeMouse(1, 0, far)))
+
+	term.handleOutput([]byte(esc("[?1005l")))
+	assert.Equal(t, "\x1b[M \xff!", string(term.encodeMouse(1, 0, far)))
+}
+
+func TestMouseTracking(t *testing.T) {
+	inBuffer := bytes.NewBuffer([]byte{})
+	term := New()
+	term.in = NopCloser(inBuffer)
+	cell := term.guessCellSize()
+	term.Resize(fyne.NewSize(cell.Width*10, cell.Height*5))
+	term.Refresh() // ensure visuals set up
+	at := func(col, row int) fyne.Position {
+		return fyne.NewPos(cell.Width*(float32(col)-.5), cell.Height*(float32(row)-.5))
+	}
+
+	term.handleOutput([]byte(esc("[?1000h") + esc("[?1006h")))
+	term.MouseDown(&desktop.MouseEvent{PointEvent: fyne.PointEvent{Position: at(2, 3)}, Button: desktop.MouseButtonSecondary})
+	term.MouseUp(&desktop.MouseEvent{PointEvent: fyne.PointEvent{Position: at(2, 3)}, Button: desktop.MouseButtonSecondary})
+	term.MouseMoved(&desktop.MouseEvent{PointEvent: fyne.PointEvent{Position: at(3, 3)}})
+	term.Scrolled(&fyne.ScrollEvent{PointEvent: fyne.PointEvent{Position: at(1, 1)}, Scrolled: fyne.NewDelta(0, -cell.Height*2)})
+	assert.Equal(t, esc("[<2;2;3M")+esc("[<2;2;3m")+esc("[<65;1;1M")+esc("[<65;1;1M"), inBuffer.String())
+
+	inBuffer.Reset()
+	term.handleOutput([]byte(esc("[?1002h")))
+	term.MouseMoved(&desktop.MouseEvent{PointEvent: fyne.PointEvent{Position: at(3, 3)}})
+	term.Dragged(&fyne.DragEvent{PointEvent: fyne.PointEvent{Position: at(4, 2)}})
+	term.Dragged(&fyne.DragEvent{PointEvent: fyne.PointEvent{Position: at(4, 2)}})
+	assert.Equal(t, esc("[<32;4;2M"), inBuffer.String())
+	assert.False(t, term.selecting)
+
+	inBuffer.Reset()
+	term.handleOutput([]byte(esc("[?1003h")))
+	term.MouseMoved(&desktop.MouseEvent{PointEvent: fyne.PointEvent{Position: at(5, 1)}})
+	term.MouseMoved(&desktop.MouseEvent{PointEvent: fyne.PointEvent{Position: at(5, 1)},
+		Modifier: fyne.KeyModifierShift})
+	term.Scrolled(&fyne.ScrollEvent{PointEvent: fyne.PointEvent{Position: at(1, 1)}, Scrolled: fyne.NewDelta(0, cell.Height)})
+	assert.Equal(t, esc("[<35;5;1M")+esc("[<64;1;1M"), inBuffer.String())
+
+	inBuffer.Reset()
+	term.handleOutput([]byte(esc("[?1003l")))
+	term.MouseMoved(&desktop.MouseEvent{PointEvent: fyne.PointEvent{Position: at(6, 1)}})
+	term.Scrolled(&fyne.ScrollEvent{PointEvent: fyne.PointEvent{Position: at(1, 1)}, Scrolled: fyne.NewDelta(0, cell.Height)})
+	assert.Equal(t, "", inBuffer.String())
+}
@@ -3,25 +3,44 @@ package terminal
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
 
 	"fyne.io/fyne/v2/storage"
 )
 
 func (t *Terminal) handleOSC(code string) {
-	if len(code) <= 2 || code[1] != ';' {
-		return
-	}
-
-	switch code[0] {
-	case '0':
+	command, arg, _ := strings.Cut(code, ";")
+	switch command {
+	case "0":
 		// set icon name, if Fyne supports in the future
-		t.setTitle(code[2:])
-	case '1':
+		t.setTitle(arg)
+	case "1":
 		// set icon name, if Fyne supports in the future
-	case '2':
-		t.setTitle(code[2:])
-	case '7':
-		t.setDirectory(code[2:])
+	case "2":
+		t.setTitle(arg)
+	case "4":
+		t.handlePaletteColors(arg)
+	case "7":
+		t.setDirectory(arg)
+	case "8":
+		t.setHyperlink(arg)
+	case "9":
+		t.handleNotification(arg)
+	case "10", "11", "12":
+		num, _ := strconv.Atoi(command)
+		t.handleDynamicColors(num, arg)
+	case "52":
+		t.handleClipboard(arg)
+	case "133":
+		t.handleSemanticPrompt(arg)
+	case "777":
+		t.handleNotifyCommand(arg)
+	case "104":
+		t.resetPaletteColors(arg)
+	case "110", "111", "112":
+		num, _ := strconv.Atoi(command)
+		t.setDynamicColor(num-100, nil)
 	default:
 		if t.debug {
 			log.Println("Unrecognised OSC:", code)
@@ -29,7 +48,44 @@ func (t *Terminal) handleOSC(code string) {
 	}
 }
 
+// handleNotification handles OSC 9, where the code is the message to show.
+// ConEmu uses the same number for other commands, these start with a number and are ignored.
+func (t *Terminal) handleNotification(code string) {
+	command, _, _ := strings.Cut(code, ";")
+	if _, err := strconv.Atoi(command); err == nil {
+		if t.debug {
+			log.Println("Unsupported ConEmu OSC:", code)
+		}
+		return
+	}
+
+	t.notify("", code)
+}
+
+// handleNotifyCommand handles OSC 777, of which we support the `notify;title;body` command.
+func (t *Terminal) handleNotifyCommand(code string) {
+	command, arg, _ := strings.Cut(code, ";")
+	if command != "notify" {
+		if t.debug {
+			log.Println("Unsupported OSC 777 command:", code)
+		}
+		return
+	}
+
+	title, body, _ := strings.Cut(arg, ";")
+	t.notify(title, body)
+}
+
+func (t *Terminal) notify(title, body string) {
+	if t.OnNotification != nil {
+		t.OnNotification(title, body)
+	}
+}
+
 func (t *Terminal) setDirectory(uri string) {
+	if uri == "" {
+		return
+	}
 	u, err := storage.ParseURI(uri)
 	if err != nil {
 		// working around a Fyne bug where file URI does not parse host
@@ -1,9 +1,18 @@
 package terminal
 
 import (
+	"bytes"
+	"encoding/base64"
+	"image/color"
+	"net/url"
 	"testing"
 
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/theme"
 	"github.com/stretchr/testify/assert"
+	widget2 "github.com/wangyiyang/Magic-Terminal/internal/widget"
 )
 
 func TestOSC_Title(t *testing.T) {
@@ -16,3 +25,212 @@ func TestOSC_Title(t *testing.T) {
 	term.handleOSC("0;Testing;123")
 	assert.Equal(t, "Testing;123", term.config.Title)
 }
+
+func TestOSC_Hyperlink(t *testing.T) {
+	term := New()
+	term.Resize(fyne.NewSize(200, 100))
+	term.handleOutput([]byte("\x1b]8;;https://example.com\x1b\\lin\r\nk\x1b]8;;\x1b\\ no"))
+
+	link := cellLink(term, 0, 0)
+	if assert.NotNil(t, link) {
+		assert.Equal(t, "https://example.com", link.URI)
+	}
+	assert.Same(t, link, cellLink(term, 0, 2))
+	assert.Same(t, link, cellLink(term, 1, 0))
+	assert.Nil(t, cellLink(term, 1, 2))
+
+	term.handleOutput([]byte("\x1b]8;id=a;https://a.com\x07a\x1b]8;;\x07 \x1b]8;id=a;https://a.com\x07a\x1b]8;;\x07"))
+	first := cellLink(term, 1, 4)
+	if assert.NotNil(t, first) {
+		assert.Equal(t, "a", first.ID)
+	}
+	assert.Same(t, first, cellLink(term, 1, 6))
+
+	term.handleOutput([]byte("\x1b]8;;https://b.com\x07b\x1b]8;;\x07\x1b]8;;https://b.com\x07b\x1b]8;;\x07"))
+	assert.NotSame(t, cellLink(term, 1, 7), cellLink(term, 1, 8))
+}
+
+func TestOSC_HyperlinkActivated(t *testing.T) {
+	term := New()
+	term.Resize(fyne.NewSize(50, 100))
+	term.handleOutput([]byte("\x1b]8;;https://example.com\x07link\x1b]8;;\x07"))
+
+	activated := ""
+	term.OnLinkActivated = func(uri string) {
+		activated = uri
+	}
+	cell := term.guessCellSize()
+	pos := fyne.NewPos(cell.Width*1.5, cell.Height/2)
+
+	term.MouseMoved(&desktop.MouseEvent{PointEvent: fyne.PointEvent{Position: pos}})
+	assert.True(t, cellLink(term, 0, 0).Hovered)
+	assert.Equal(t, desktop.PointerCursor, term.Cursor())
+
+	term.MouseDown(&desktop.MouseEvent{PointEvent: fyne.PointEvent{Position: pos}, Button: desktop.MouseButtonPrimary})
+	assert.Equal(t, "", activated)
+	term.MouseDown(&desktop.MouseEvent{PointEvent: fyne.PointEvent{Position: pos},
+		Button: desktop.MouseButtonPrimary, Modifier: fyne.KeyModifierShortcutDefault})
+	assert.Equal(t, "https://example.com", activated)
+
+	term.MouseOut()
+	assert.False(t, cellLink(term, 0, 0).Hovered)
+	assert.Equal(t, desktop.DefaultCursor, term.Cursor())
+}
+
+func TestOSC_HyperlinkDefaultSchemes(t *testing.T) {
+	a := &urlApp{App: test.NewApp()}
+	fyne.SetCurrentApp(a)
+	defer fyne.SetCurrentApp(a.App)
+
+	term := New()
+	for _, uri := range []string{"https://example.com", "file:///etc/passwd", "MAILTO:me@example.com", "x-custom:run"} {
+		term.activateLink(uri)
+	}
+	assert.Equal(t, []string{"https://example.com", "mailto:me@example.com"}, a.opened)
+}
+
+func TestOSC_HyperlinkAltScreen(t *testing.T) {
+	term := New()
+	term.Resize(fyne.NewSize(200, 100))
+	term.handleOutput([]byte("\x1b]8;;https://example.com\x1b\\a\x1b[?1049hb"))
+	assert.Nil(t, cellLink(term, 0, 0))
+
+	term.handleOutput([]byte("\x1b]8;;https://example.com\x1b\\c\x1b[?1049ld"))
+	assert.NotNil(t, cellLink(term, 0, 0))
+	assert.Nil(t, cellLink(term, 0, 1))
+}
+
+// urlApp records the URLs that it is asked to open.
+type urlApp struct {
+	fyne.App
+	opened []string
+}
+
+func (a *urlApp) OpenURL(u *url.URL) error {
+	a.opened = append(a.opened, u.String())
+	return nil
+}
+
+func cellLink(term *Terminal, row, col int) *widget2.Hyperlink {
+	cells := term.content.Row(row).Cells
+	if col >= len(cells) {
+		return nil
+	}
+	if s, ok := cells[col].Style.(*widget2.TermTextGridStyle); ok {
+		return s.Link
+	}
+	return nil
+}
+
+func TestOSC_Clipboard(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString([]byte("copied"))
+	tests := map[string]struct {
+		policy    ClipboardPolicy
+		input     string
+		clipboard string
+		reply     string
+	}{
+		"write":             {policy: ClipboardWriteOnly, input: "\x1b]52;c;" + encoded + "\x07", clipboard: "copied"},
+		"write denied":      {policy: ClipboardDeny, input: "\x1b]52;c;" + encoded + "\x07", clipboard: "before"},
+		"write over limit":  {policy: ClipboardWriteOnly, input: "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte("too long!")) + "\x07", clipboard: "before"},
+		"write invalid":     {policy: ClipboardWriteOnly, input: "\x1b]52;c;!!!\x07", clipboard: "before"},
+		"write no window":   {policy: ClipboardAsk, input: "\x1b]52;c;" + encoded + "\x07", clipboard: "before"},
+		"read":              {policy: ClipboardReadWrite, input: "\x1b]52;c;?\x07", clipboard: "before", reply: "\x1b]52;c;YmVmb3Jl\x1b\\"},
+		"read write only":   {policy: ClipboardWriteOnly, input: "\x1b]52;c;?\x07", clipboard: "before"},
+		"read denied":       {policy: ClipboardDeny, input: "\x1b]52;c;?\x07", clipboard: "before"},
+		"missing selection": {policy: ClipboardReadWrite, input: "\x1b]52;?\x07", clipboard: "before"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.NewApp().Clipboard().SetContent("before")
+			inBuffer := bytes.NewBuffer([]byte{})
+			term := New()
+			term.in = NopCloser(inBuffer)
+			term.SetClipboardPolicy(tt.policy)
+			term.SetClipboardLimit(8)
+
+			term.handleOutput([]byte(tt.input))
+			assert.Equal(t, tt.clipboard, fyne.CurrentApp().Clipboard().Content())
+			assert.Equal(t, tt.reply, inBuffer.String())
+		})
+	}
+}
+
+func TestOSC_Palette(t *testing.T) {
+	inBuffer := bytes.NewBuffer([]byte{})
+	term := New()
+	term.in = NopCloser(inBuffer)
+	term.Resize(fyne.NewSize(200, 100))
+
+	term.handleOutput([]byte("\x1b]4;1;?\x07"))
+	assert.Equal(t, "\x1b]4;1;rgb:aaaa/0000/0000\x1b\\", inBuffer.String())
+
+	term.handleOutput([]byte("\x1b[31mA\x1b[38;5;100mB\x1b[m"))
+	inBuffer.Reset()
+	term.handleOutput([]byte("\x1b]4;1;rgb:12/34/56;100;#fff;2;bad\x07\x1b]4;1;?;100;?;2;?\x07"))
+	assert.Equal(t, "\x1b]4;1;rgb:1212/3434/5656\x1b\\\x1b]4;100;rgb:ffff/ffff/ffff\x1b\\"+
+		"\x1b]4;2;rgb:0000/aaaa/0000\x1b\\", inBuffer.String())
+	cells := term.content.Row(0).Cells
+	assert.Equal(t, &color.RGBA64{R: 0x1212, G: 0x3434, B: 0x5656, A: 0xffff}, cells[0].Style.TextColor())
+	assert.Equal(t, &color.RGBA64{R: 0xffff, G: 0xffff, B: 0xffff, A: 0xffff}, cells[1].Style.TextColor())
+
+	term.handleOutput([]byte("\x1b]104;1\x07"))
+	assert.Equal(t, basicColors[1], cells[0].Style.TextColor())
+	assert.Equal(t, &color.RGBA64{R: 0xffff, G: 0xffff, B: 0xffff, A: 0xffff}, cells[1].Style.TextColor())
+	term.handleOutput([]byte("\x1b]104\x07"))
+	assert.Equal(t, &color.RGBA{0x87, 0x87, 0, 0xff}, cells[1].Style.TextColor())
+}
+
+func TestOSC_DynamicColors(t *testing.T) {
+	inBuffer := bytes.NewBuffer([]byte{})
+	term := New()
+	term.in = NopCloser(inBuffer)
+	term.Resize(fyne.NewSize(200, 100))
+
+	term.handleOutput([]byte("\x1b]10;?\x07"))
+	assert.Equal(t, "\x1b]10;"+formatColorSpec(theme.Color(theme.ColorNameForeground))+"\x1b\\", inBuffer.String())
+
+	inBuffer.Reset()
+	term.handleOutput([]byte("\x1b]10;#102030;#405060\x07\x1b]12;rgb:f/0/0\x07\x1b]10;?;?;?\x07"))
+	assert.Equal(t, "\x1b]10;rgb:1010/2020/3030\x1b\\\x1b]11;rgb:4040/5050/6060\x1b\\\x1b]12;rgb:ffff/0000/0000\x1b\\",
+		inBuffer.String())
+	assert.Equal(t, &color.RGBA64{R: 0x1010, G: 0x2020, B: 0x3030, A: 0xffff},
+		term.content.Theme().Color(theme.ColorNameForeground, theme.VariantDark))
+	assert.Equal(t, &color.RGBA64{R: 0xffff, A: 0xffff}, term.cursor.FillColor)
+
+	term.handleOutput([]byte("\x1b]110\x07\x1b]111\x07\x1b]112\x07"))
+	assert.Nil(t, term.defaultFG)
+	assert.Nil(t, term.defaultBG)
+	assert.Nil(t, term.cursorColor)
+}
+
+func TestOSC_Notification(t *testing.T) {
+	tests := map[string]struct {
+		input       string
+		title, body string
+		notified    bool
+	}{
+		"osc 9":           {input: "\x1b]9;Build done\x07", body: "Build done", notified: true},
+		"osc 9 separator": {input: "\x1b]9;Build; done\x07", body: "Build; done", notified: true},
+		"conemu progress": {input: "\x1b]9;4;1;50\x07"},
+		"osc 777":         {input: "\x1b]777;notify;Build;Finished; 0 errors\x1b\\", title: "Build", body: "Finished; 0 errors", notified: true},
+		"osc 777 other":   {input: "\x1b]777;preexec\x07"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			term := New()
+			notified := false
+			var title, body string
+			term.OnNotification = func(t, b string) {
+				notified, title, body = true, t, b
+			}
+
+			term.handleOutput([]byte(tt.input))
+			assert.Equal(t, tt.notified, notified)
+			assert.Equal(t, tt.title, title)
+			assert.Equal(t, tt.body, body)
+		})
+	}
+}
@@ -2,18 +2,22 @@ package terminal
 
 import (
 	"bytes"
+	"image/color"
 	"time"
 	"unicode/utf8"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/widget"
 	widget2 "github.com/wangyiyang/Magic-Terminal/internal/widget"
+	"golang.org/x/text/unicode/norm"
 )
 
 const (
-	asciiBell      = 7
-	asciiBackspace = 8
-	asciiEscape    = 27
+	asciiBell       = 7
+	asciiBackspace  = 8
+	asciiCancel     = 24
+	asciiSubstitute = 26
+	asciiEscape     = 27
 
 	noEscape = 5000
 	tabWidth = 8
@@ -89,6 +93,7 @@ type parseState struct {
 	osc      bool
 	vt100    rune
 	apc      bool
+	dcs      bool
 	printing bool
 }
 
@@ -136,6 +141,10 @@ func (t *Terminal) handleOutput(buf []byte) []byte {
 			t.parseAPC(r)
 			continue
 		}
+		if t.state.dcs {
+			t.parseDCS(r)
+			continue
+		}
 		if t.state.osc {
 			t.parseOSC(r)
 			continue
@@ -171,15 +180,21 @@ func (t *Terminal) handleOutput(buf []byte) []byte {
 }
 
 func (t *Terminal) parseEscState(r rune) (shouldContinue bool) {
+	if t.state.dcs && r != '\\' {
+		t.cancelDCS() // any escape other than the string terminator aborts the DCS
+	}
+
 	switch r {
 	case '[':
 		return true
 	case '\\':
 		if t.state.osc {
 			t.handleOSC(t.state.code)
+		} else if t.state.dcs {
+			t.handleDCS(t.state.code)
 		}
 		t.state.code = ""
-		t.state.osc = false
+		t.state.osc, t.state.dcs = false, false
 	case ']':
 		t.state.osc = true
 	case '(', ')':
@@ -196,14 +211,20 @@ func (t *Terminal) parseEscState(r rune) (shouldContinue bool) {
 		t.scrollUp()
 	case '_':
 		t.state.apc = true
-	case '=', '>':
+	case 'P':
+		t.state.code = ""
+		t.state.dcs = true
+	case '=':
+		t.appKeypad = true
+	case '>':
+		t.appKeypad = false
 	}
 	return false
 }
 
 func (t *Terminal) parseEscape(r rune) {
 	t.state.code += string(r)
-	if (r < '0' || r > '9') && r != ';' && r != '=' && r != '?' && r != '>' {
+	if r < ' ' || r > '?' { // parameters and intermediates are followed by the final character
 		t.handleEscape(t.state.code)
 		t.state.code = ""
 		t.state.esc = noEscape
@@ -230,6 +251,19 @@ func (t *Terminal) parseAPC(r rune) {
 	}
 }
 
+func (t *Terminal) parseDCS(r rune) {
+	if r == asciiCancel || r == asciiSubstitute {
+		t.cancelDCS()
+	} else {
+		t.state.code += string(r)
+	}
+}
+
+func (t *Terminal) cancelDCS() {
+	t.state.code = ""
+	t.state.dcs = false
+}
+
 func (t *Terminal) parseOSC(r rune) {
 	if r == asciiBell || r == 0 {
 		t.handleOSC(t.state.code)
@@ -241,8 +275,28 @@ func (t *Terminal) parseOSC(r rune) {
 }
 
 func (t *Terminal) handleOutputChar(r rune) {
-	if t.cursorCol >= int(t.config.Columns) || t.cursorRow >= int(t.config.Rows) {
-		return // TODO handle wrap?
+	if t.config.Columns == 0 {
+		return
+	}
+	w := runeWidth(r)
+	if (w == 0 || t.joinsPreviousChar()) && t.combineWithPreviousChar(r) {
+		return
+	}
+	if w == 0 {
+		w = 1 // nothing to combine with, show it on its own
+	} else if w == 2 && t.config.Columns < 2 {
+		w = 1
+	}
+
+	if t.cursorCol >= int(t.config.Columns) || (w == 2 && t.cursorCol == int(t.config.Columns)-1) {
+		if t.wrapDisabled {
+			t.cursorCol = int(t.config.Columns) - w // overwrite the last column
+		} else {
+			t.wrapLine()
+		}
+	}
+	if t.cursorRow >= int(t.config.Rows) {
+		return
 	}
 	for len(t.content.Rows)-1 < t.cursorRow {
 		t.content.Rows = append(t.content.Rows, widget.TextGridRow{})
@@ -250,18 +304,145 @@ func (t *Terminal) handleOutputChar(r rune) {
 
 	var cellStyle widget.TextGridStyle
 	cellStyle = &widget.CustomTextGridStyle{FGColor: t.currentFG, BGColor: t.currentBG}
-	for len(t.content.Rows[t.cursorRow].Cells)-1 < t.cursorCol {
+	for len(t.content.Rows[t.cursorRow].Cells)-1 < t.cursorCol+w-1 {
 		newCell := widget.TextGridCell{
 			Rune:  ' ',
 			Style: cellStyle,
 		}
 		t.content.Rows[t.cursorRow].Cells = append(t.content.Rows[t.cursorRow].Cells, newCell)
 	}
-	if t.blinking {
-		cellStyle = widget2.NewTermTextGridStyle(t.currentFG, t.currentBG, highlightBitMask, t.blinking)
+	cellStyle = t.newCellStyle()
+
+	t.clearWideChar(t.cursorRow, t.cursorCol)
+	if w == 2 {
+		t.clearWideChar(t.cursorRow, t.cursorCol+1)
+	}
+	t.setCell(t.cursorRow, t.cursorCol, widget.TextGridCell{Rune: r, Style: cellStyle})
+	if w == 2 {
+		cont, ok := t.newCellStyle().(*widget2.TermTextGridStyle)
+		if !ok {
+			cont = widget2.NewTermTextGridStyle(t.currentFG, t.currentBG, highlightBitMask, false).(*widget2.TermTextGridStyle)
+		}
+		cont.Continuation = true
+		t.setCell(t.cursorRow, t.cursorCol+1, widget.TextGridCell{Style: cont})
+	}
+	t.cursorCol += w
+	if t.wrapDisabled && t.cursorCol >= int(t.config.Columns) {
+		t.cursorCol = int(t.config.Columns) - 1 // no wrap is pending, the next character replaces the last
+	}
+}
+
+// newCellStyle returns the style for a character written with the current colours and attributes.
+func (t *Terminal) newCellStyle() widget.TextGridStyle {
+	if !t.blinking && t.attributes == 0 && t.hyperlink == nil {
+		return &widget.CustomTextGridStyle{FGColor: t.currentFG, BGColor: t.currentBG}
+	}
+
+	s := widget2.NewTermTextGridStyle(t.currentFG, t.currentBG, highlightBitMask, t.blinking).(*widget2.TermTextGridStyle)
+	s.Attributes = t.attributes
+	s.Link = t.hyperlink
+	if t.attributes&widget2.AttributeUnderline != 0 {
+		s.UnderlineStyle, s.UnderlineColor = t.underlineStyle, t.underlineColor
+	}
+	return s
+}
+
+// clearWideChar blanks both halves of a double width character if the given cell is part of one,
+// overwriting just one half would leave a broken character on screen. The blanks take the current colours.
+func (t *Terminal) clearWideChar(row, col int) {
+	cells := t.content.Row(row).Cells
+	if col >= len(cells) {
+		return
+	}
+
+	if widget2.IsContinuation(cells[col]) {
+		if col > 0 {
+			t.setCell(row, col-1, widget.TextGridCell{Rune: ' ', Style: t.newCellStyle()})
+		}
+		t.setCell(row, col, widget.TextGridCell{Rune: ' ', Style: t.newCellStyle()})
+	} else if col+1 < len(cells) && widget2.IsContinuation(cells[col+1]) {
+		t.setCell(row, col+1, widget.TextGridCell{Rune: ' ', Style: t.newCellStyle()})
 	}
-	t.content.SetCell(t.cursorRow, t.cursorCol, widget.TextGridCell{Rune: r, Style: cellStyle})
-	t.cursorCol++
+}
+
+// previousChar returns the position of the character written before the cursor, following wrapped lines
+// and skipping the continuation of double width characters.
+func (t *Terminal) previousChar() (row, col int, ok bool) {
+	row, col = t.cursorRow, t.cursorCol-1
+	if col < 0 {
+		if row == 0 || !t.content.RowInfo(row-1).Wrapped {
+			return 0, 0, false
+		}
+		row--
+		col = len(t.content.Row(row).Cells) - 1
+	}
+
+	cells := t.content.Row(row).Cells
+	if col >= len(cells) {
+		return 0, 0, false
+	}
+	if widget2.IsContinuation(cells[col]) {
+		col--
+	}
+	if col < 0 || cells[col].Rune == 0 {
+		return 0, 0, false
+	}
+	return row, col, true
+}
+
+// joinsPreviousChar returns true if the character before the cursor ends with a zero width joiner,
+// in which case the next character is part of the same emoji sequence.
+func (t *Terminal) joinsPreviousChar() bool {
+	row, col, ok := t.previousChar()
+	if !ok {
+		return false
+	}
+
+	s, ok := t.content.Rows[row].Cells[col].Style.(*widget2.TermTextGridStyle)
+	return ok && len(s.Combining) > 0 && s.Combining[len(s.Combining)-1] == zeroWidthJoiner
+}
+
+// combineWithPreviousChar adds a rune to the grapheme cluster before the cursor.
+// Where a precomposed character exists it replaces the cell rune so that it renders correctly.
+func (t *Terminal) combineWithPreviousChar(r rune) bool {
+	row, col, ok := t.previousChar()
+	if !ok {
+		return false
+	}
+
+	cell := t.content.Rows[row].Cells[col]
+	s, ok := cell.Style.(*widget2.TermTextGridStyle)
+	if !ok {
+		var fg, bg color.Color
+		if cell.Style != nil {
+			fg, bg = cell.Style.TextColor(), cell.Style.BackgroundColor()
+		}
+		s = widget2.NewTermTextGridStyle(fg, bg, highlightBitMask, false).(*widget2.TermTextGridStyle)
+		cell.Style = s
+	}
+
+	if len(s.Combining) == 0 {
+		if composed := []rune(norm.NFC.String(string([]rune{cell.Rune, r}))); len(composed) == 1 {
+			cell.Rune = composed[0]
+			t.setCell(row, col, cell)
+			return true
+		}
+	}
+	s.Combining = append(s.Combining, r)
+	t.setCell(row, col, cell)
+	return true
+}
+
+// wrapLine moves the cursor to the start of the next line, marking the current row as continuing there.
+func (t *Terminal) wrapLine() {
+	t.setRowWrapped(t.cursorRow, true)
+
+	if t.cursorRow == t.scrollBottom {
+		t.scrollDown()
+		t.moveCursor(t.cursorRow, 0)
+		return
+	}
+	t.moveCursor(t.cursorRow+1, 0)
 }
 
 func (t *Terminal) ringBell() {
@@ -276,26 +457,40 @@ func (t *Terminal) ringBell() {
 }
 
 func (t *Terminal) scrollUp() {
+	for len(t.content.Rows) <= t.scrollBottom {
+		t.content.Rows = append(t.content.Rows, widget.TextGridRow{})
+	}
 	for i := t.scrollBottom; i > t.scrollTop; i-- {
 		t.content.Rows[i] = t.content.Row(i - 1)
+		t.content.SetRowInfo(i, t.content.RowInfo(i-1))
 	}
 	t.content.Rows[t.scrollTop] = widget.TextGridRow{}
-	t.content.Refresh()
+	t.content.SetRowInfo(t.scrollTop, widget2.RowInfo{})
+	t.refreshContent()
 }
 
 func (t *Terminal) scrollDown() {
-	i := t.scrollTop
-	for ; i < t.scrollBottom && i < len(t.content.Rows)-1; i++ {
-		t.content.Rows[i] = t.content.Row(i + 1)
+	if t.scrollTop == 0 && t.scrollBottom == int(t.config.Rows)-1 && !t.altScreen {
+		t.addToScrollback(t.content.Row(0), t.content.RowInfo(0))
 	}
-	for ; i < len(t.content.Rows); i++ {
-		if len(t.content.Rows) > t.scrollBottom {
-			t.content.Rows[t.scrollBottom] = widget.TextGridRow{}
-		} else {
-			t.content.Rows = append(t.content.Rows, widget.TextGridRow{})
-		}
+
+	for len(t.content.Rows) <= t.scrollBottom {
+		t.content.Rows = append(t.content.Rows, widget.TextGridRow{})
 	}
-	t.content.Refresh()
+	for i := t.scrollTop; i < t.scrollBottom; i++ {
+		t.content.Rows[i] = t.content.Row(i + 1)
+		t.content.SetRowInfo(i, t.content.RowInfo(i+1))
+	}
+	t.content.Rows[t.scrollBottom] = widget.TextGridRow{}
+	t.content.SetRowInfo(t.scrollBottom, widget2.RowInfo{})
+	t.refreshContent()
+}
+
+// setRowWrapped marks whether the text of the given row continues on the next row.
+func (t *Terminal) setRowWrapped(row int, wrapped bool) {
+	info := t.content.RowInfo(row)
+	info.Wrapped = wrapped
+	t.content.SetRowInfo(row, info)
 }
 
 func handleOutputBackspace(t *Terminal) {
@@ -332,6 +527,9 @@ func handleOutputLineFeed(t *Terminal) {
 
 func handleOutputTab(t *Terminal) {
 	end := t.cursorCol - t.cursorCol%tabWidth + tabWidth
+	if end >= int(t.config.Columns) {
+		end = int(t.config.Columns) - 1 // tabs stop at the right margin and never wrap
+	}
 	for t.cursorCol < end {
 		t.handleOutputChar(' ')
 	}
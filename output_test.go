@@ -19,3 +19,130 @@ func TestTerminal_Backspace(t *testing.T) {
 
 	assert.Equal(t, "Hello", term.content.Text())
 }
+
+func TestTerminal_AutoWrap(t *testing.T) {
+	term := New()
+	term.config.Columns = 5
+	term.config.Rows = 2
+	term.scrollBottom = 1
+	term.Refresh() // ensure visuals set up
+
+	term.handleOutput([]byte("Hello"))
+	assert.Equal(t, 0, term.cursorRow)
+	assert.Equal(t, 5, term.cursorCol) // pending wrap, nothing moves until the next character
+
+	term.handleOutput([]byte("World"))
+	assert.Equal(t, 1, term.cursorRow)
+	assert.Equal(t, 5, term.cursorCol)
+	assert.Equal(t, "HelloWorld", term.Text())
+	assert.Equal(t, 2, len(term.content.Rows))
+
+	term.handleOutput([]byte("!\r\nNext"))
+	assert.Equal(t, "!\nNext", term.Text())
+	assert.Equal(t, "HelloWorld!\nNext", term.TextWithScrollback())
+}
+
+func TestTerminal_AutoWrapErased(t *testing.T) {
+	term := New()
+	term.config.Columns = 5
+	term.config.Rows = 3
+	term.scrollBottom = 2
+	term.Refresh() // ensure visuals set up
+
+	term.handleOutput([]byte("HelloWorld\x1b[1;3H\x1b[K"))
+	assert.False(t, term.content.RowInfo(0).Wrapped)
+	assert.Equal(t, "He\nWorld", term.Text())
+
+	term.handleOutput([]byte("\x1b[HHelloWorld\x1b[2J\x1b[3;1Hab"))
+	assert.False(t, term.content.RowInfo(0).Wrapped)
+	assert.Equal(t, "\n\nab", term.Text())
+
+	term.handleOutput([]byte("\x1b[HHelloWorld\x1b[H\x1b[L"))
+	assert.False(t, term.content.RowInfo(0).Wrapped) // the blank line inserted is not wrapped
+	assert.True(t, term.content.RowInfo(1).Wrapped)
+	assert.Equal(t, "\nHelloWorld", term.Text())
+}
+
+func TestTerminal_AutoWrapDisabled(t *testing.T) {
+	term := New()
+	term.config.Columns = 5
+	term.config.Rows = 2
+	term.scrollBottom = 1
+	term.Refresh() // ensure visuals set up
+
+	term.handleOutput([]byte("\x1b[?7lHello World"))
+	assert.Equal(t, "Helld", term.Text())
+	assert.Equal(t, 0, term.cursorRow)
+	assert.Equal(t, 4, term.cursorCol)
+
+	term.handleOutput([]byte("\x1b[?7h!"))
+	assert.Equal(t, "Hell!", term.Text())
+	assert.Equal(t, 0, term.cursorRow)
+
+	term.handleOutput([]byte("?"))
+	assert.Equal(t, "Hell!?", term.Text()) // wrapped on to the next row
+	assert.Equal(t, 1, term.cursorRow)
+}
+
+func TestTerminal_WideChars(t *testing.T) {
+	term := New()
+	term.config.Columns = 5
+	term.config.Rows = 2
+	term.scrollBottom = 1
+	term.Refresh() // ensure visuals set up
+
+	term.handleOutput([]byte("a中文"))
+	assert.Equal(t, 5, term.cursorCol)
+	assert.Equal(t, 5, len(term.content.Rows[0].Cells))
+	assert.Equal(t, "a中文", term.Text())
+
+	term.handleOutput([]byte("\x1b[1;3Hb"))
+	assert.Equal(t, "a b文", term.Text())
+
+	term.handleOutput([]byte("\x1b[1;1Hxyzw字"))
+	assert.Equal(t, 1, term.cursorRow)
+	assert.Equal(t, 2, term.cursorCol)
+	assert.Equal(t, "xyzw 字", term.Text())
+}
+
+func TestTerminal_WideCharOverwriteKeepsBackground(t *testing.T) {
+	term := New()
+	term.config.Columns = 5
+	term.config.Rows = 2
+	term.Refresh() // ensure visuals set up
+
+	term.handleOutput([]byte("中\x1b[1;2H\x1b[41mb"))
+	assert.Equal(t, " b", term.Text())
+	blank := term.content.Rows[0].Cells[0]
+	assert.Equal(t, ' ', blank.Rune)
+	assert.Equal(t, basicColors[1], blank.Style.BackgroundColor())
+}
+
+func TestTerminal_CombiningChars(t *testing.T) {
+	term := New()
+	term.config.Columns = 10
+	term.config.Rows = 2
+	term.scrollBottom = 1
+	term.Refresh() // ensure visuals set up
+
+	term.handleOutput([]byte("e\u0301x\u0304\u0301"))
+	assert.Equal(t, 2, term.cursorCol)
+	assert.Equal(t, 'é', term.content.Rows[0].Cells[0].Rune)
+	assert.Equal(t, "éx\u0304\u0301", term.Text())
+
+	family := "\U0001F468\u200d\U0001F469\u200d\U0001F467"
+	term.handleOutput([]byte("\r\n" + family + "!"))
+	assert.Equal(t, 3, term.cursorCol)
+	assert.Equal(t, "éx\u0304\u0301\n"+family+"!", term.Text())
+}
+
+func TestTerminal_EscapeIntermediates(t *testing.T) {
+	term := New()
+	term.config.Columns = 10
+	term.config.Rows = 2
+	term.Refresh() // ensure visuals set up
+
+	term.handleOutput([]byte("a" + esc("[2 q") + "b" + esc("[0\"q") + "c" + esc("[!p") + "d"))
+	assert.Equal(t, "abcd", term.Text())
+	assert.Equal(t, 2, term.cursorShape)
+}
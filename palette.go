@@ -0,0 +1,255 @@
+package terminal
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+	widget2 "github.com/wangyiyang/Magic-Terminal/internal/widget"
+)
+
+// defaultPalette is the standard 256 colour palette: the basic and bright colours, a 6x6x6 colour cube and a grey ramp.
+var defaultPalette = func() (p [256]color.Color) {
+	copy(p[:8], basicColors)
+	copy(p[8:16], brightColors)
+	for id := 0; id < 216; id++ {
+		r, g, b := id/36, id/6%6, id%6
+		p[16+id] = &color.RGBA{colourBands[r], colourBands[g], colourBands[b], 255}
+	}
+	for id := 0; id < 24; id++ {
+		p[232+id] = &color.Gray{uint8(id * (256 / 24))}
+	}
+	return p
+}()
+
+// paletteColor returns the colour at index id of the palette, which may have been changed by the application.
+func (t *Terminal) paletteColor(id int) color.Color {
+	if c := t.palette[id]; c != nil {
+		return c
+	}
+	return defaultPalette[id]
+}
+
+// paletteChange collects the palette colours changed by one escape sequence,
+// so that the text already drawn in them is updated in a single pass.
+type paletteChange map[int]color.Color // the colour that each changed index had before
+
+// setPaletteColor changes the palette colour at index id, or resets it to the default if c is nil.
+func (t *Terminal) setPaletteColor(change paletteChange, id int, c color.Color) {
+	if _, ok := change[id]; !ok {
+		change[id] = t.paletteColor(id)
+	}
+	t.palette[id] = c
+}
+
+// applyPaletteChange changes the colours replaced in the palette in all the text we have,
+// as well as in the current graphics modes.
+func (t *Terminal) applyPaletteChange(change paletteChange) {
+	replacements := make(map[color.Color]color.Color)
+	for id, old := range change {
+		if c := t.paletteColor(id); c != old {
+			replacements[old] = c
+		}
+	}
+	if len(replacements) == 0 {
+		return
+	}
+
+	if t.content != nil {
+		widget2.ReplaceColors(t.content.Rows, replacements, highlightBitMask)
+	}
+	widget2.ReplaceColors(t.primaryRows, replacements, highlightBitMask)
+	widget2.ReplaceColors(t.altRows, replacements, highlightBitMask)
+	if t.scrollback != nil {
+		widget2.ReplaceColors(t.scrollback.rows, replacements, highlightBitMask)
+	}
+
+	for _, col := range []*color.Color{&t.currentFG, &t.currentBG, &t.underlineColor} {
+		if c, ok := replacements[*col]; ok && *col != nil {
+			*col = c
+		}
+	}
+}
+
+// handlePaletteColors handles OSC 4, which is a list of `index;spec` pairs.
+// Each spec sets the colour at that index, or is `?` to ask for the current colour.
+func (t *Terminal) handlePaletteColors(code string) {
+	change := paletteChange{}
+	parts := strings.Split(code, ";")
+	for i := 0; i+1 < len(parts); i += 2 {
+		id, err := strconv.Atoi(parts[i])
+		if err != nil || id < 0 || id > 255 {
+			if t.debug {
+				log.Println("Invalid palette index", parts[i])
+			}
+			continue
+		}
+
+		if parts[i+1] == "?" {
+			t.reply("%c]4;%d;%s%c\\", asciiEscape, id, formatColorSpec(t.paletteColor(id)), asciiEscape)
+		} else if c := t.parseColorSpec(parts[i+1]); c != nil {
+			t.setPaletteColor(change, id, c)
+		}
+	}
+	t.applyPaletteChange(change)
+}
+
+// resetPaletteColors handles OSC 104, resetting the listed palette indexes or the whole palette if there are none.
+func (t *Terminal) resetPaletteColors(code string) {
+	change := paletteChange{}
+	defer t.applyPaletteChange(change)
+	if code == "" {
+		for id, c := range t.palette {
+			if c != nil {
+				t.setPaletteColor(change, id, nil)
+			}
+		}
+		return
+	}
+
+	for _, index := range strings.Split(code, ";") {
+		id, err := strconv.Atoi(index)
+		if err != nil || id < 0 || id > 255 {
+			if t.debug {
+				log.Println("Invalid palette index", index)
+			}
+			continue
+		}
+		t.setPaletteColor(change, id, nil)
+	}
+}
+
+// handleDynamicColors handles OSC 10, 11 and 12, which set or query (with `?`) the default text colour,
+// default background colour and cursor colour. Further specs continue with the next colour, as in xterm.
+func (t *Terminal) handleDynamicColors(command int, code string) {
+	for i, spec := range strings.Split(code, ";") {
+		num := command + i
+		if num > 12 {
+			break
+		}
+
+		if spec == "?" {
+			t.reply("%c]%d;%s%c\\", asciiEscape, num, formatColorSpec(t.dynamicColor(num)), asciiEscape)
+		} else if c := t.parseColorSpec(spec); c != nil {
+			t.setDynamicColor(num, c)
+		}
+	}
+}
+
+// dynamicColor returns the colour for OSC 10, 11 or 12, falling back to the theme if it has not been set.
+func (t *Terminal) dynamicColor(num int) color.Color {
+	v := fyne.CurrentApp().Settings().ThemeVariant()
+	switch num {
+	case 10:
+		if t.defaultFG != nil {
+			return t.defaultFG
+		}
+		return t.Theme().Color(theme.ColorNameForeground, v)
+	case 11:
+		if t.defaultBG != nil {
+			return t.defaultBG
+		}
+		return t.Theme().Color(theme.ColorNameBackground, v)
+	default:
+		if t.cursorColor != nil {
+			return t.cursorColor
+		}
+		return t.Theme().Color(theme.ColorNamePrimary, v)
+	}
+}
+
+// setDynamicColor sets the colour for OSC 10, 11 or 12, a nil colour resets it to the theme colour.
+func (t *Terminal) setDynamicColor(num int, c color.Color) {
+	switch num {
+	case 10:
+		t.defaultFG = c
+	case 11:
+		t.defaultBG = c
+	default:
+		t.cursorColor = c
+	}
+	t.Refresh()
+}
+
+// parseColorSpec parses an X11 colour specification, in the `rgb:r/g/b` form with 1 to 4 hex digits per channel
+// or the `#rgb` form with 1 to 4 digits. Colour names are not supported.
+func (t *Terminal) parseColorSpec(spec string) color.Color {
+	values, ok := parseColorChannels(spec)
+	if !ok {
+		if t.debug {
+			log.Println("Unsupported colour", spec)
+		}
+		return nil
+	}
+
+	return &color.RGBA64{R: values[0], G: values[1], B: values[2], A: 0xffff}
+}
+
+func parseColorChannels(spec string) (values [3]uint16, ok bool) {
+	var channels []string
+	if rgb, ok := strings.CutPrefix(spec, "rgb:"); ok {
+		channels = strings.Split(rgb, "/")
+	} else if hex, ok := strings.CutPrefix(spec, "#"); ok && len(hex)%3 == 0 {
+		size := len(hex) / 3
+		channels = []string{hex[:size], hex[size : size*2], hex[size*2:]}
+	}
+	if len(channels) != 3 {
+		return values, false
+	}
+
+	for i, channel := range channels {
+		v, err := strconv.ParseUint(channel, 16, 16)
+		if err != nil || len(channel) > 4 {
+			return values, false
+		}
+		// scale to 16 bits, so that "f" and "ffff" are both full intensity
+		max := uint64(1)<<(4*len(channel)) - 1
+		values[i] = uint16(v * 0xffff / max)
+	}
+	return values, true
+}
+
+// formatColorSpec returns the colour as an X11 colour specification, which is how colour queries are answered.
+func formatColorSpec(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("rgb:%04x/%04x/%04x", r, g, b)
+}
+
+// colorTheme gives the text grids the default colours set with OSC 10 and 11, or those of the terminal's theme.
+type colorTheme struct {
+	term *Terminal
+}
+
+func (c *colorTheme) Color(n fyne.ThemeColorName, v fyne.ThemeVariant) color.Color {
+	if c.term.screenReversed { // DECSCNM swaps the default colours
+		switch n {
+		case theme.ColorNameForeground:
+			n = theme.ColorNameBackground
+		case theme.ColorNameBackground:
+			n = theme.ColorNameForeground
+		}
+	}
+	switch {
+	case n == theme.ColorNameForeground && c.term.defaultFG != nil:
+		return c.term.defaultFG
+	case n == theme.ColorNameBackground && c.term.defaultBG != nil:
+		return c.term.defaultBG
+	}
+	return c.term.Theme().Color(n, v)
+}
+
+func (c *colorTheme) Font(s fyne.TextStyle) fyne.Resource {
+	return c.term.Theme().Font(s)
+}
+
+func (c *colorTheme) Icon(n fyne.ThemeIconName) fyne.Resource {
+	return c.term.Theme().Icon(n)
+}
+
+func (c *colorTheme) Size(n fyne.ThemeSizeName) float32 {
+	return c.term.Theme().Size(n)
+}
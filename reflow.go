@@ -0,0 +1,174 @@
+package terminal
+
+import (
+	"fyne.io/fyne/v2/widget"
+	widget2 "github.com/wangyiyang/Magic-Terminal/internal/widget"
+)
+
+// reflow re-wraps the scrollback history and the primary screen to fit the current number of columns.
+// The cursor (or the saved cursor if the alternate screen is showing) stays on the same character.
+func (t *Terminal) reflow() {
+	cols, rows := int(t.config.Columns), int(t.config.Rows)
+	if t.content == nil || cols == 0 || rows == 0 {
+		return
+	}
+	if t.hasSelectedText() {
+		t.clearSelectedText()
+	}
+	t.scrollOffset = 0
+
+	screen, screenInfos, row, col := t.content.Rows, t.content.RowInfos, t.cursorRow, t.cursorCol
+	if t.altScreen {
+		screen, screenInfos, row, col = t.primaryRows, t.primaryInfos, t.savedRow, t.savedCol
+	}
+
+	history := t.scrollback.len()
+	all := make([]widget.TextGridRow, 0, history+len(screen))
+	infos := make([]widget2.RowInfo, 0, history+len(screen))
+	for i := 0; i < history; i++ {
+		all = append(all, t.scrollback.row(i))
+		infos = append(infos, t.scrollback.info(i))
+	}
+	all = append(all, screen...)
+	infos = append(infos, screenInfos...)
+	for len(all) <= history+row {
+		all = append(all, widget.TextGridRow{})
+	}
+	for len(infos) < len(all) {
+		infos = append(infos, widget2.RowInfo{})
+	}
+	infos = infos[:len(all)]
+
+	all, infos, row, col = reflowRows(all, infos, cols, history+row, col)
+	for len(all)-1 > row && len(all[len(all)-1].Cells) == 0 {
+		all = all[:len(all)-1] // blank lines below the cursor can be recreated by the application
+		infos = infos[:len(all)]
+	}
+
+	top := len(all) - rows
+	if top < 0 {
+		top = 0
+	} else if top > row {
+		top = row
+	}
+	t.scrollback.clear()
+	for i, r := range all[:top] {
+		t.scrollback.push(r, infos[i])
+	}
+	screen, screenInfos = all[top:], infos[top:]
+	if len(screen) > rows {
+		screen, screenInfos = screen[:rows], screenInfos[:rows]
+	}
+	for len(screen) < rows {
+		screen = append(screen, widget.TextGridRow{}) // the application can still address every row
+		screenInfos = append(screenInfos, widget2.RowInfo{})
+	}
+	row -= top
+
+	if t.altScreen {
+		t.primaryRows, t.primaryInfos, t.savedRow, t.savedCol = screen, screenInfos, row, col
+		if t.cursorRow >= rows {
+			t.cursorRow = rows - 1
+		}
+		if t.cursorCol > cols {
+			t.cursorCol = cols
+		}
+		return
+	}
+
+	t.content.Rows, t.content.RowInfos, t.cursorRow, t.cursorCol = screen, screenInfos, row, col
+	if t.cursorMoved != nil {
+		t.cursorMoved()
+	}
+}
+
+// reflowRows joins rows that were wrapped into logical lines and splits them again at the given width.
+// The infos hold what is known about each of the rows, they are returned for the new rows.
+// The row and col passed in are translated to the position of the same character in the returned rows.
+func reflowRows(rows []widget.TextGridRow, infos []widget2.RowInfo, cols, row, col int) (
+	[]widget.TextGridRow, []widget2.RowInfo, int, int) {
+	out := make([]widget.TextGridRow, 0, len(rows))
+	outInfos := make([]widget2.RowInfo, 0, len(rows))
+	newRow, newCol := row, col
+
+	for i := 0; i < len(rows); i++ {
+		start := i
+		for i < len(rows)-1 && infos[i].Wrapped {
+			i++
+		}
+
+		var cells []widget.TextGridCell
+		var marks []widget2.PromptMark // with the column counted from the start of the line
+		cursor := -1
+		for j := start; j <= i; j++ {
+			if j == row {
+				cursor = len(cells) + col
+			}
+			for _, m := range infos[j].Marks {
+				m.Col += len(cells)
+				marks = append(marks, m)
+			}
+			cells = append(cells, rows[j].Cells...)
+		}
+		for len(cells) > 0 && len(cells) > cursor && isBlankCell(cells[len(cells)-1]) {
+			cells = cells[:len(cells)-1]
+		}
+
+		first := len(out)
+		var starts []int
+		for off := 0; ; {
+			starts = append(starts, off)
+			rest := cells[off:]
+			if len(rest) <= cols {
+				out = append(out, widget.TextGridRow{Cells: rest})
+				outInfos = append(outInfos, widget2.RowInfo{})
+				break
+			}
+			split := cols
+			if split > 1 && widget2.IsContinuation(rest[split]) {
+				split-- // don't break a double width character over two rows
+			}
+			out = append(out, widget.TextGridRow{Cells: rest[:split:split]})
+			outInfos = append(outInfos, widget2.RowInfo{Wrapped: true})
+			off += split
+		}
+		chunkOf := func(off int) int {
+			chunk := len(starts) - 1
+			for chunk > 0 && starts[chunk] > off {
+				chunk--
+			}
+			return chunk
+		}
+		for _, m := range marks {
+			chunk := chunkOf(m.Col)
+			m.Col -= starts[chunk]
+			outInfos[first+chunk].Marks = append(outInfos[first+chunk].Marks, m)
+		}
+
+		if cursor < 0 {
+			continue
+		}
+		chunk := chunkOf(cursor)
+		newRow, newCol = first+chunk, cursor-starts[chunk]
+		if newCol > cols || (newCol == cols && cursor != len(cells)) {
+			newCol = cols - 1 // past the end of the text, stay on the last row
+		}
+	}
+
+	return out, outInfos, newRow, newCol
+}
+
+// isBlankCell returns true if the cell shows nothing, so that it can be dropped from the end of a line.
+// Spaces with a background colour or an attribute such as underline are kept.
+func isBlankCell(c widget.TextGridCell) bool {
+	if c.Rune != ' ' && c.Rune != 0 {
+		return false
+	}
+	if c.Style == nil {
+		return true
+	}
+	if s, ok := c.Style.(*widget2.TermTextGridStyle); ok && (s.Continuation || s.Attributes != 0) {
+		return false
+	}
+	return c.Style.BackgroundColor() == nil
+}
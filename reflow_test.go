@@ -0,0 +1,114 @@
+package terminal
+
+import (
+	"strings"
+	"testing"
+
+	"fyne.io/fyne/v2/widget"
+	"github.com/stretchr/testify/assert"
+	widget2 "github.com/wangyiyang/Magic-Terminal/internal/widget"
+)
+
+func TestReflowRows(t *testing.T) {
+	rows := []widget.TextGridRow{textRow("Hello"), textRow("World"), textRow("ab   ")}
+	infos := []widget2.RowInfo{{Wrapped: true}, {}, {}}
+
+	out, infos, row, col := reflowRows(rows, infos, 4, 1, 2)
+	assert.Equal(t, 4, len(out))
+	assert.Equal(t, "Hell", rowText(out[0]))
+	assert.Equal(t, "oWor", rowText(out[1]))
+	assert.Equal(t, "ld", rowText(out[2]))
+	assert.Equal(t, "ab", rowText(out[3]))
+	assert.Equal(t, 4, len(infos))
+	assert.True(t, infos[1].Wrapped)
+	assert.False(t, infos[2].Wrapped)
+	assert.Equal(t, 1, row) // still on the 'r' of World
+	assert.Equal(t, 3, col)
+
+	out, _, row, col = reflowRows(out, infos, 20, 3, 2)
+	assert.Equal(t, 2, len(out))
+	assert.Equal(t, "HelloWorld", rowText(out[0]))
+	assert.Equal(t, "ab", rowText(out[1]))
+	assert.Equal(t, 1, row)
+	assert.Equal(t, 2, col)
+}
+
+func TestReflowRows_Marks(t *testing.T) {
+	command := &widget2.CommandMark{}
+	rows := []widget.TextGridRow{textRow("$ abc"), textRow("de")}
+	infos := []widget2.RowInfo{
+		{Wrapped: true, Marks: []widget2.PromptMark{{Col: 0, Command: command}, {Col: 2, Zone: widget2.ZoneInput, Command: command}}},
+		{Marks: []widget2.PromptMark{{Col: 1, Zone: widget2.ZoneOutput, Command: command}}},
+	}
+
+	_, infos, _, _ = reflowRows(rows, infos, 3, 0, 0)
+	assert.Equal(t, 3, len(infos))
+	assert.Equal(t, []widget2.PromptMark{{Col: 0, Command: command}, {Col: 2, Zone: widget2.ZoneInput, Command: command}},
+		infos[0].Marks)
+	assert.Empty(t, infos[1].Marks)
+	assert.Equal(t, []widget2.PromptMark{{Col: 0, Zone: widget2.ZoneOutput, Command: command}}, infos[2].Marks)
+}
+
+func TestReflowRows_PendingWrap(t *testing.T) {
+	out, _, row, col := reflowRows([]widget.TextGridRow{textRow("abcdef")}, make([]widget2.RowInfo, 1), 3, 0, 6)
+	assert.Equal(t, 2, len(out))
+	assert.Equal(t, 1, row)
+	assert.Equal(t, 3, col)
+}
+
+func TestReflowRows_StyledBlanks(t *testing.T) {
+	styled := textRow("ab  ")
+	for i := range styled.Cells {
+		styled.Cells[i].Style = &widget.CustomTextGridStyle{BGColor: basicColors[1]}
+	}
+	bar := textRow("   ")
+	bar.Cells[1].Style = &widget.CustomTextGridStyle{BGColor: basicColors[4]}
+
+	out, _, _, _ := reflowRows([]widget.TextGridRow{textRow("$ "), styled, bar}, make([]widget2.RowInfo, 3), 10, 0, 2)
+	assert.Equal(t, 3, len(out))
+	assert.Equal(t, "$ ", rowText(out[0]))
+	assert.Equal(t, "ab  ", rowText(out[1]))
+	assert.Equal(t, "  ", rowText(out[2]))
+}
+
+func TestTerminal_Reflow(t *testing.T) {
+	term := New()
+	term.config.Columns = 12
+	term.config.Rows = 2
+	term.scrollBottom = 1
+	term.Refresh() // ensure visuals set up
+
+	term.handleOutput([]byte("HelloWorld!\r\n$ "))
+	assert.Equal(t, "HelloWorld!\n$ ", term.Text())
+
+	term.config.Columns = 5
+	term.reflow()
+	assert.Equal(t, "!\n$ ", term.Text())
+	assert.Equal(t, "HelloWorld!\n$ ", term.TextWithScrollback())
+	assert.Equal(t, 1, term.cursorRow)
+	assert.Equal(t, 2, term.cursorCol)
+
+	term.config.Columns = 20
+	term.reflow()
+	assert.Equal(t, "HelloWorld!\n$ ", term.Text())
+	assert.Equal(t, 0, term.scrollback.len())
+	assert.Equal(t, 1, term.cursorRow)
+	assert.Equal(t, 2, term.cursorCol)
+}
+
+func TestTerminal_ReflowKeepsScreenRows(t *testing.T) {
+	term := New()
+	term.config.Columns = 10
+	term.config.Rows = 3
+	term.scrollBottom = 2
+	term.Refresh() // ensure visuals set up
+
+	term.handleOutput([]byte("a\r\nb\r\nc\x1b[2J\x1b[H"))
+	term.config.Columns = 8
+	term.reflow()
+	assert.Equal(t, 3, len(term.content.Rows))
+
+	term.handleOutput([]byte("\x1bMtop"))
+	assert.Equal(t, "top", strings.TrimRight(term.content.Text(), "\n"))
+	assert.Equal(t, 3, len(term.content.Rows))
+}
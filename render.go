@@ -5,6 +5,7 @@ import (
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/theme"
 	widget2 "github.com/wangyiyang/Magic-Terminal/internal/widget"
 )
@@ -13,10 +14,17 @@ const cursorWidth = 2
 
 type render struct {
 	term *Terminal
+
+	background *canvas.Rectangle // shows behind empty cells in the default background or in reverse video
+	colors     *container.ThemeOverride
+	theme      fyne.Theme // the theme of the text grids once colors is applied, to check it was not replaced
 }
 
 func (r *render) Layout(s fyne.Size) {
+	r.background.Resize(s)
+	r.colors.Resize(s)
 	r.term.content.Resize(s)
+	r.term.history.Resize(s)
 }
 
 func (r *render) MinSize() fyne.Size {
@@ -26,7 +34,19 @@ func (r *render) MinSize() fyne.Size {
 func (r *render) Refresh() {
 	r.moveCursor()
 	r.term.refreshCursor()
+	r.term.refreshHistory()
 
+	r.background.FillColor = color.Transparent
+	if r.term.screenReversed {
+		r.background.FillColor = r.term.dynamicColor(10)
+	} else if r.term.defaultBG != nil {
+		r.background.FillColor = r.term.defaultBG
+	}
+	r.background.Refresh()
+	if r.term.content.Theme() != r.theme { // a theme override around the terminal was refreshed
+		r.colors.Refresh()
+		r.theme = r.term.content.Theme()
+	}
 	r.term.content.Refresh()
 }
 
@@ -35,7 +55,7 @@ func (r *render) BackgroundColor() color.Color {
 }
 
 func (r *render) Objects() []fyne.CanvasObject {
-	return []fyne.CanvasObject{r.term.content, r.term.cursor}
+	return []fyne.CanvasObject{r.background, r.colors, r.term.cursor}
 }
 
 func (r *render) Destroy() {
@@ -43,32 +63,61 @@ func (r *render) Destroy() {
 
 func (r *render) moveCursor() {
 	cell := r.term.guessCellSize()
-	r.term.cursor.Move(fyne.NewPos(cell.Width*float32(r.term.cursorCol), cell.Height*float32(r.term.cursorRow)))
+	row := r.term.cursorRow + r.term.scrollOffset // the cursor moves down as we view history
+	pos := fyne.NewPos(cell.Width*float32(r.term.cursorCol), cell.Height*float32(row))
+	if r.term.underlineCursor() {
+		pos.Y += cell.Height - cursorWidth
+	}
+	r.term.cursor.Move(pos)
 }
 
 func (t *Terminal) refreshCursor() {
-	t.cursor.Hidden = !t.focused || t.cursorHidden
+	t.cursor.Hidden = !t.focused || t.cursorHidden || t.cursorRow+t.scrollOffset >= int(t.config.Rows)
+	fill := t.dynamicColor(12)
 	if t.bell {
-		t.cursor.FillColor = theme.Color(theme.ColorNameError)
-	} else {
-		t.cursor.FillColor = theme.Color(theme.ColorNamePrimary)
+		fill = theme.Color(theme.ColorNameError)
+	}
+
+	cell := t.guessCellSize()
+	switch {
+	case t.blockCursor():
+		r, g, b, _ := fill.RGBA() // a block covers the character, so let it show through
+		t.cursor.FillColor = color.NRGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: 0x80}
+		t.cursor.Resize(cell)
+	case t.underlineCursor():
+		t.cursor.FillColor = fill
+		t.cursor.Resize(fyne.NewSize(cell.Width, cursorWidth))
+	default:
+		t.cursor.FillColor = fill
+		t.cursor.Resize(fyne.NewSize(cursorWidth, cell.Height))
 	}
-	t.cursor.Resize(fyne.NewSize(cursorWidth, t.guessCellSize().Height))
 	t.cursor.Refresh()
 }
 
+func (t *Terminal) blockCursor() bool {
+	return t.cursorShape == 1 || t.cursorShape == 2
+}
+
+func (t *Terminal) underlineCursor() bool {
+	return t.cursorShape == 3 || t.cursorShape == 4
+}
+
 // CreateRenderer requests a new renderer for this terminal (just a wrapper around the TextGrid)
 func (t *Terminal) CreateRenderer() fyne.WidgetRenderer {
 	t.ExtendBaseWidget(t)
 
 	t.content = widget2.NewTermGrid()
+	t.history = widget2.NewTermGrid()
+	t.history.Hide()
 	t.setupShortcuts()
 
 	t.cursor = canvas.NewRectangle(theme.Color(theme.ColorNamePrimary))
 	t.cursor.Hidden = true
 	t.cursor.Resize(fyne.NewSize(cursorWidth, t.guessCellSize().Height))
 
-	r := &render{term: t}
+	r := &render{term: t, background: canvas.NewRectangle(color.Transparent)}
+	r.colors = container.NewThemeOverride(container.NewWithoutLayout(t.content, t.history), &colorTheme{term: t})
+	r.theme = t.content.Theme()
 	t.cursorMoved = r.moveCursor
 	return r
 }
@@ -0,0 +1,231 @@
+package terminal
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"strconv"
+	"strings"
+
+	widget2 "github.com/wangyiyang/Magic-Terminal/internal/widget"
+)
+
+// Version is the Magic Terminal version reported to applications that ask for it (XTVERSION).
+// Release builds set it with -ldflags "-X github.com/wangyiyang/Magic-Terminal.Version=x.y.z".
+var Version = "dev"
+
+const (
+	// deviceAttributes identifies as a VT220 (62) with ANSI colour (22) support.
+	deviceAttributes = "?62;22"
+	// deviceType is the VT220 terminal type used in the secondary device attributes.
+	deviceType = 1
+)
+
+// reply sends a response to a query from the application, as if it were typed by the user.
+func (t *Terminal) reply(format string, args ...interface{}) {
+	if t.in == nil {
+		return
+	}
+	_, _ = t.in.Write([]byte(fmt.Sprintf(format, args...)))
+}
+
+// escapeDeviceStatusReport answers a device status report (DSR) request.
+// Positions are absolute as origin mode is not supported.
+func escapeDeviceStatusReport(t *Terminal, msg string) {
+	if strings.HasPrefix(msg, ">") { // xterm uses the same final to reset a key modifier option
+		t.handleModifyKeys(msg[1:])
+		return
+	}
+
+	switch msg {
+	case "5": // operating status, always OK
+		t.reply("%c[0n", asciiEscape)
+	case "6": // cursor position report
+		row, col := t.reportCursorPosition()
+		t.reply("%c[%d;%dR", asciiEscape, row, col)
+	case "?6": // DEC extended cursor position report, with the page number
+		row, col := t.reportCursorPosition()
+		t.reply("%c[?%d;%d;1R", asciiEscape, row, col)
+	default:
+		if t.debug {
+			log.Println("Unsupported device status report", msg)
+		}
+	}
+}
+
+// reportCursorPosition returns the 1 based cursor position to report to the application.
+func (t *Terminal) reportCursorPosition() (int, int) {
+	col := t.cursorCol
+	if cols := int(t.config.Columns); cols > 0 && col >= cols {
+		col = cols - 1 // a pending wrap reports the last column
+	}
+	return t.cursorRow + 1, col + 1
+}
+
+// escapeDeviceAttributes answers the primary (DA1), secondary (DA2) and tertiary (DA3) device attribute requests.
+func escapeDeviceAttributes(t *Terminal, msg string) {
+	switch strings.TrimSuffix(msg, "0") {
+	case "":
+		t.reply("%c[%sc", asciiEscape, deviceAttributes)
+	case ">":
+		t.reply("%c[>%d;%d;0c", asciiEscape, deviceType, versionNumber())
+	case "=": // the unit ID, which we do not have
+		t.reply("%cP!|00000000%c\\", asciiEscape, asciiEscape)
+	default:
+		if t.debug {
+			log.Println("Unsupported device attributes request", msg)
+		}
+	}
+}
+
+// escapeReportVersion answers the XTVERSION request with the name and version of the terminal.
+func escapeReportVersion(t *Terminal, msg string) {
+	if strings.TrimSuffix(msg, "0") != ">" {
+		if t.debug {
+			log.Println("Unrecognised Escape:", msg+"q")
+		}
+		return
+	}
+
+	t.reply("%cP>|Magic-Terminal(%s)%c\\", asciiEscape, Version, asciiEscape)
+}
+
+// versionNumber encodes the Version as a single number, for example "1.2.3" becomes 10203.
+// Versions that do not parse, such as development builds, return 0.
+func versionNumber() int {
+	parts := strings.SplitN(strings.TrimPrefix(Version, "v"), ".", 3)
+	num := 0
+	for i := 0; i < 3; i++ {
+		num *= 100
+		if i >= len(parts) {
+			continue
+		}
+		n, err := strconv.Atoi(strings.SplitN(parts[i], "-", 2)[0])
+		if err != nil {
+			return 0
+		}
+		num += n
+	}
+	return num
+}
+
+// escapeRequestMode answers a request for the state of a mode (DECRQM).
+// Private modes are looked up in the registry, we do not recognise any ANSI modes.
+func escapeRequestMode(t *Terminal, msg string) {
+	mode, ok := strings.CutSuffix(msg, "$")
+	if !ok {
+		if t.debug {
+			log.Println("Unrecognised Escape:", msg+"p")
+		}
+		return
+	}
+
+	state := 0 // not recognised
+	if num, ok := strings.CutPrefix(mode, "?"); ok {
+		id, err := strconv.Atoi(num)
+		if m, ok := privateModes[id]; ok && err == nil {
+			state = 2 // reset
+			if m.get(t) {
+				state = 1 // set
+			}
+		}
+	}
+	t.reply("%c[%s;%d$y", asciiEscape, mode, state)
+}
+
+// requestStatusString answers a request for a control function setting (DECRQSS).
+// The reply holds the sequence that would restore the current setting, or is marked invalid if we do not know it.
+func (t *Terminal) requestStatusString(setting string) {
+	var value string
+	switch setting {
+	case "m":
+		value = t.graphicsModes() + "m"
+	case "r":
+		value = fmt.Sprintf("%d;%dr", t.scrollTop+1, t.scrollBottom+1)
+	case " q":
+		value = fmt.Sprintf("%d q", t.reportCursorShape())
+	case "\"q":
+		value = "0\"q" // DECSCA, characters are never protected
+	default:
+		if t.debug {
+			log.Println("Unsupported status string request", setting)
+		}
+		t.reply("%cP0$r%c\\", asciiEscape, asciiEscape)
+		return
+	}
+
+	t.reply("%cP1$r%s%c\\", asciiEscape, value, asciiEscape)
+}
+
+// reportCursorShape returns the DECSCUSR style of the cursor, the default is reported as the steady bar it draws.
+func (t *Terminal) reportCursorShape() int {
+	if t.cursorShape == 0 {
+		return 6
+	}
+	return t.cursorShape
+}
+
+// graphicsModes returns the SGR parameters that select the current colours and attributes.
+func (t *Terminal) graphicsModes() string {
+	modes := []string{"0"}
+	if t.attributes&widget2.AttributeBold != 0 {
+		modes = append(modes, "1")
+	}
+	if t.attributes&widget2.AttributeDim != 0 {
+		modes = append(modes, "2")
+	}
+	if t.attributes&widget2.AttributeItalic != 0 {
+		modes = append(modes, "3")
+	}
+	if t.attributes&widget2.AttributeUnderline != 0 {
+		if t.underlineStyle == widget2.UnderlineSingle {
+			modes = append(modes, "4")
+		} else {
+			modes = append(modes, "4:"+strconv.Itoa(int(t.underlineStyle)+1))
+		}
+	}
+	if t.blinking {
+		modes = append(modes, "5")
+	}
+	if t.attributes&widget2.AttributeInverse != 0 {
+		modes = append(modes, "7")
+	}
+	if t.attributes&widget2.AttributeHidden != 0 {
+		modes = append(modes, "8")
+	}
+	if t.attributes&widget2.AttributeStrikethrough != 0 {
+		modes = append(modes, "9")
+	}
+	if t.attributes&widget2.AttributeOverline != 0 {
+		modes = append(modes, "53")
+	}
+	if t.currentFG != nil {
+		modes = append(modes, t.colorMode(t.currentFG, 30, 90, "38"))
+	}
+	if t.currentBG != nil {
+		modes = append(modes, t.colorMode(t.currentBG, 40, 100, "48"))
+	}
+	if t.underlineColor != nil {
+		modes = append(modes, t.colorMode(t.underlineColor, -1, -1, "58"))
+	}
+	return strings.Join(modes, ";")
+}
+
+// colorMode returns the SGR parameters that select the colour c.
+// Palette colours use the basic or bright mode, if available, or the 256 colour mode. Anything else is sent as RGB.
+func (t *Terminal) colorMode(c color.Color, basic, bright int, extended string) string {
+	for i := 0; i < 256; i++ {
+		if c != t.paletteColor(i) {
+			continue
+		}
+		if i < 8 && basic >= 0 {
+			return strconv.Itoa(basic + i)
+		} else if i < 16 && bright >= 0 {
+			return strconv.Itoa(bright + i - 8)
+		}
+		return fmt.Sprintf("%s;5;%d", extended, i)
+	}
+
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("%s;2;%d;%d;%d", extended, r>>8, g>>8, b>>8)
+}
@@ -0,0 +1,195 @@
+package terminal
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeviceStatusReport(t *testing.T) {
+	tests := map[string]struct {
+		input, want string
+	}{
+		"status":            {input: esc("[5n"), want: esc("[0n")},
+		"cursor home":       {input: esc("[6n"), want: esc("[1;1R")},
+		"cursor moved":      {input: esc("[3;4H") + esc("[6n"), want: esc("[3;4R")},
+		"pending wrap":      {input: "abcde" + esc("[6n"), want: esc("[1;5R")},
+		"extended position": {input: esc("[2;3H") + esc("[?6n"), want: esc("[?2;3;1R")},
+		"unknown":           {input: esc("[99n"), want: ""},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			inBuffer := bytes.NewBuffer([]byte{})
+			term := New()
+			term.in = NopCloser(inBuffer)
+			term.config.Columns = 5
+			term.config.Rows = 5
+			term.Refresh() // ensure visuals set up
+
+			term.handleOutput([]byte(tt.input))
+			assert.Equal(t, tt.want, inBuffer.String())
+		})
+	}
+}
+
+func TestDeviceAttributes(t *testing.T) {
+	tests := map[string]struct {
+		input, want string
+	}{
+		"primary":         {input: esc("[c"), want: esc("[?62;22c")},
+		"primary zero":    {input: esc("[0c"), want: esc("[?62;22c")},
+		"secondary":       {input: esc("[>c"), want: esc("[>1;0;0c")},
+		"tertiary":        {input: esc("[=c"), want: esc("P!|00000000") + esc("\\")},
+		"version":         {input: esc("[>q"), want: esc("P>|Magic-Terminal(dev)") + esc("\\")},
+		"version with id": {input: esc("[>0q"), want: esc("P>|Magic-Terminal(dev)") + esc("\\")},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			inBuffer := bytes.NewBuffer([]byte{})
+			term := New()
+			term.in = NopCloser(inBuffer)
+
+			term.handleOutput([]byte(tt.input))
+			assert.Equal(t, tt.want, inBuffer.String())
+		})
+	}
+}
+
+func TestVersionNumber(t *testing.T) {
+	defer func(v string) { Version = v }(Version)
+
+	for v, want := range map[string]int{"dev": 0, "1.2.3": 10203, "v0.10.1-4-gabcdef": 1001, "2.1": 20100} {
+		Version = v
+		assert.Equal(t, want, versionNumber(), v)
+	}
+}
+
+func TestSynchronizedOutput(t *testing.T) {
+	inBuffer := bytes.NewBuffer([]byte{})
+	term := New()
+	term.in = NopCloser(inBuffer)
+	term.config.Columns = 5
+	term.config.Rows = 2
+	term.Refresh() // ensure visuals set up
+
+	term.handleOutput([]byte(esc("[?2026$p")))
+	assert.Equal(t, esc("[?2026;2$y"), inBuffer.String())
+
+	inBuffer.Reset()
+	term.handleOutput([]byte(esc("[?2026h") + esc("[?2026$p")))
+	assert.True(t, term.syncUpdate)
+	assert.Equal(t, esc("[?2026;1$y"), inBuffer.String())
+
+	term.handleOutput([]byte(esc("[?2026l")))
+	assert.False(t, term.syncUpdate)
+	assert.Nil(t, term.syncTimer)
+
+	term.handleOutput([]byte(esc("[?2026h")))
+	term.syncUpdateTimedOut()
+	assert.False(t, term.syncUpdate)
+
+	inBuffer.Reset()
+	term.handleOutput([]byte(esc("[?9999$p")))
+	assert.Equal(t, esc("[?9999;0$y"), inBuffer.String())
+}
+
+func TestSynchronizedOutput_HoldsDrawing(t *testing.T) {
+	term := New()
+	term.config.Columns = 5
+	term.config.Rows = 2
+	term.Refresh() // ensure visuals set up
+	cursor := term.cursor.Position()
+
+	term.handleOutput([]byte(esc("[?2026h") + "ab" + esc("[2;3H") + "c"))
+	assert.Equal(t, "ab\n  c", term.content.Text())
+	assert.Equal(t, cursor, term.cursor.Position())
+
+	term.handleOutput([]byte(esc("[?2026l")))
+	term.Refresh()
+	assert.NotEqual(t, cursor, term.cursor.Position())
+}
+
+func TestRequestMode(t *testing.T) {
+	tests := map[string]struct {
+		input, want string
+	}{
+		"autowrap default":  {input: esc("[?7$p"), want: esc("[?7;1$y")},
+		"cursor keys reset": {input: esc("[?1$p"), want: esc("[?1;2$y")},
+		"cursor keys set":   {input: esc("[?1h") + esc("[?1$p"), want: esc("[?1;1$y")},
+		"mouse tracking":    {input: esc("[?1002h") + esc("[?1002$p") + esc("[?1000$p"), want: esc("[?1002;1$y") + esc("[?1000;2$y")},
+		"unknown private":   {input: esc("[?12345$p"), want: esc("[?12345;0$y")},
+		"ansi mode":         {input: esc("[4$p"), want: esc("[4;0$y")},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			inBuffer := bytes.NewBuffer([]byte{})
+			term := New()
+			term.in = NopCloser(inBuffer)
+			term.config.Columns = 5
+			term.config.Rows = 5
+			term.Refresh() // ensure visuals set up
+
+			term.handleOutput([]byte(tt.input))
+			assert.Equal(t, tt.want, inBuffer.String())
+		})
+	}
+}
+
+func TestRequestStatusString(t *testing.T) {
+	tests := map[string]struct {
+		input, want string
+	}{
+		"sgr default":   {input: esc("P$qm") + esc("\\"), want: esc("P1$r0m") + esc("\\")},
+		"sgr attribute": {input: esc("[1;4:3;7m") + esc("P$qm") + esc("\\"), want: esc("P1$r0;1;4:3;7m") + esc("\\")},
+		"sgr colours":   {input: esc("[31;102m") + esc("P$qm") + esc("\\"), want: esc("P1$r0;31;102m") + esc("\\")},
+		"sgr rgb":       {input: esc("[38;2;1;2;3m") + esc("P$qm") + esc("\\"), want: esc("P1$r0;38;2;1;2;3m") + esc("\\")},
+		"sgr palette":   {input: esc("[38;5;100;48;5;9m") + esc("P$qm") + esc("\\"), want: esc("P1$r0;38;5;100;101m") + esc("\\")},
+		"margins":       {input: esc("[2;4r") + esc("P$qr") + esc("\\"), want: esc("P1$r2;4r") + esc("\\")},
+		"cursor style":  {input: esc("P$q q") + esc("\\"), want: esc("P1$r6 q") + esc("\\")},
+		"cursor shape":  {input: esc("[3 q") + esc("P$q q") + esc("\\"), want: esc("P1$r3 q") + esc("\\")},
+		"unknown":       {input: esc("P$qx") + esc("\\"), want: esc("P0$r") + esc("\\")},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			inBuffer := bytes.NewBuffer([]byte{})
+			term := New()
+			term.in = NopCloser(inBuffer)
+			term.config.Columns = 5
+			term.config.Rows = 5
+			term.Refresh() // ensure visuals set up
+
+			term.handleOutput([]byte(tt.input))
+			assert.Equal(t, tt.want, inBuffer.String())
+		})
+	}
+}
+
+func TestRequestCapabilities(t *testing.T) {
+	tests := map[string]struct {
+		input, want string
+	}{
+		"number":  {input: esc("P+q436f") + esc("\\"), want: esc("P1+r436f=323536") + esc("\\")},
+		"boolean": {input: esc("P+q5463") + esc("\\"), want: esc("P1+r5463") + esc("\\")},
+		"string":  {input: esc("P+q536d756c78") + esc("\\"), want: esc("P1+r536d756c78=1B5B343A25703125646D") + esc("\\")},
+		"several": {input: esc("P+q544E;6b6273") + esc("\\"),
+			want: esc("P1+r544E=787465726D2D323536636F6C6F72") + esc("\\") + esc("P1+r6b6273=08") + esc("\\")},
+		"unknown": {input: esc("P+q78797A") + esc("\\"), want: esc("P0+r78797A") + esc("\\")},
+		"invalid": {input: esc("P+qxyz") + esc("\\"), want: esc("P0+rxyz") + esc("\\")},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			inBuffer := bytes.NewBuffer([]byte{})
+			term := New()
+			term.in = NopCloser(inBuffer)
+
+			term.handleOutput([]byte(tt.input))
+			assert.Equal(t, tt.want, inBuffer.String())
+		})
+	}
+}
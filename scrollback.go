@@ -0,0 +1,223 @@
+package terminal
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+	widget2 "github.com/wangyiyang/Magic-Terminal/internal/widget"
+)
+
+const defaultScrollbackLines = 1000
+
+// scrollback is a ring buffer holding the rows that have scrolled off the top of the screen.
+// The oldest row is dropped once the configured maximum is reached.
+type scrollback struct {
+	rows         []widget.TextGridRow
+	infos        []widget2.RowInfo // what is known about each row, at the same index as the row
+	start, count int
+}
+
+func newScrollback(lines int) *scrollback {
+	if lines < 0 {
+		lines = 0
+	}
+	return &scrollback{rows: make([]widget.TextGridRow, lines), infos: make([]widget2.RowInfo, lines)}
+}
+
+// len returns the number of rows currently stored.
+func (s *scrollback) len() int {
+	if s == nil {
+		return 0
+	}
+	return s.count
+}
+
+// push adds a row as the newest entry, discarding the oldest if the buffer is full.
+func (s *scrollback) push(row widget.TextGridRow, info widget2.RowInfo) {
+	if s == nil || len(s.rows) == 0 {
+		return
+	}
+
+	if s.count < len(s.rows) {
+		i := (s.start + s.count) % len(s.rows)
+		s.rows[i], s.infos[i] = row, info
+		s.count++
+		return
+	}
+
+	s.rows[s.start], s.infos[s.start] = row, info
+	s.start = (s.start + 1) % len(s.rows)
+}
+
+// row returns the row at index i, where 0 is the oldest row stored.
+func (s *scrollback) row(i int) widget.TextGridRow {
+	if s == nil || i < 0 || i >= s.count {
+		return widget.TextGridRow{}
+	}
+	return s.rows[(s.start+i)%len(s.rows)]
+}
+
+// info returns what is known about the row at index i, where 0 is the oldest row stored.
+func (s *scrollback) info(i int) widget2.RowInfo {
+	if s == nil || i < 0 || i >= s.count {
+		return widget2.RowInfo{}
+	}
+	return s.infos[(s.start+i)%len(s.rows)]
+}
+
+// resize changes the maximum number of rows, keeping the newest rows if it shrinks.
+func (s *scrollback) resize(lines int) {
+	if lines < 0 {
+		lines = 0
+	}
+	keep := s.count
+	if keep > lines {
+		keep = lines
+	}
+
+	rows := make([]widget.TextGridRow, lines)
+	infos := make([]widget2.RowInfo, lines)
+	for i := 0; i < keep; i++ {
+		rows[i], infos[i] = s.row(s.count-keep+i), s.info(s.count-keep+i)
+	}
+	s.rows, s.infos, s.start, s.count = rows, infos, 0, keep
+}
+
+// clear removes all rows from the history.
+func (s *scrollback) clear() {
+	if s == nil {
+		return
+	}
+	for i := range s.rows {
+		s.rows[i], s.infos[i] = widget.TextGridRow{}, widget2.RowInfo{}
+	}
+	s.start, s.count = 0, 0
+}
+
+// SetScrollbackLines sets how many lines that scroll off the top of the screen are kept for viewing later.
+// Passing 0 disables the scrollback history.
+func (t *Terminal) SetScrollbackLines(lines int) {
+	if t.scrollback == nil {
+		t.scrollback = newScrollback(lines)
+	} else {
+		t.scrollback.resize(lines)
+	}
+
+	if t.scrollOffset > t.scrollback.len() {
+		t.scrollOffset = t.scrollback.len()
+	}
+}
+
+// TextWithScrollback returns the contents of the scrollback history followed by the visible buffer,
+// joined with `\n` (no style information).
+func (t *Terminal) TextWithScrollback() string {
+	grid := widget2.NewTermGrid()
+	for i := 0; i < t.scrollback.len(); i++ {
+		grid.Rows = append(grid.Rows, t.scrollback.row(i))
+		grid.RowInfos = append(grid.RowInfos, t.scrollback.info(i))
+	}
+	for i, row := range t.content.Rows {
+		grid.Rows = append(grid.Rows, row)
+		grid.RowInfos = append(grid.RowInfos, t.content.RowInfo(i))
+	}
+	return grid.Text()
+}
+
+// Scrolled is called when the user scrolls over the terminal, it moves the view through the scrollback history.
+// If the application is tracking the mouse the wheel movement is reported to it instead.
+func (t *Terminal) Scrolled(ev *fyne.ScrollEvent) {
+	lines := int(ev.Scrolled.DY / t.guessCellSize().Height)
+	if lines == 0 {
+		if ev.Scrolled.DY > 0 {
+			lines = 1
+		} else if ev.Scrolled.DY < 0 {
+			lines = -1
+		}
+	}
+
+	if mods := t.keyboardModifiers(); t.reportingMouse(mods) {
+		t.reportMouseWheel(lines, mods, *t.sanitizePosition(ev.Position))
+		return
+	}
+	t.scrollHistory(lines)
+}
+
+func (t *Terminal) addToScrollback(row widget.TextGridRow, info widget2.RowInfo) {
+	t.scrollback.push(row, info)
+	if t.scrollOffset > 0 && t.scrollOffset < t.scrollback.len() {
+		t.scrollOffset++ // keep the same lines in view as the history grows
+	}
+}
+
+// scrollHistory moves the view the given number of lines back into the history, negative values move forward.
+func (t *Terminal) scrollHistory(lines int) {
+	if t.altScreen {
+		return // full screen applications manage their own history
+	}
+	offset := t.scrollOffset + lines
+	if offset > t.scrollback.len() {
+		offset = t.scrollback.len()
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	if offset == t.scrollOffset {
+		return
+	}
+
+	if t.hasSelectedText() {
+		t.clearSelectedText() // the selection is held in view positions
+	}
+	t.scrollOffset = offset
+	t.Refresh()
+}
+
+// scrollToBottom returns the view to the live screen if we were looking at the history.
+func (t *Terminal) scrollToBottom() {
+	if t.scrollOffset == 0 {
+		return
+	}
+
+	if t.hasSelectedText() {
+		t.clearSelectedText()
+	}
+	t.scrollOffset = 0
+	t.Refresh()
+}
+
+// historyRows returns the rows that should be visible for the current scroll offset, and what is known about them.
+func (t *Terminal) historyRows() ([]widget.TextGridRow, []widget2.RowInfo) {
+	rows := int(t.config.Rows)
+	start := t.scrollback.len() - t.scrollOffset
+
+	view := make([]widget.TextGridRow, 0, rows)
+	infos := make([]widget2.RowInfo, 0, rows)
+	for i := start; i < start+rows; i++ {
+		if i < t.scrollback.len() {
+			view = append(view, t.scrollback.row(i))
+			infos = append(infos, t.scrollback.info(i))
+		} else {
+			view = append(view, t.content.Row(i-t.scrollback.len()))
+			infos = append(infos, t.content.RowInfo(i-t.scrollback.len()))
+		}
+	}
+	return view, infos
+}
+
+func (t *Terminal) refreshHistory() {
+	if t.history == nil {
+		return
+	}
+	if t.scrollOffset == 0 {
+		if !t.history.Hidden {
+			t.history.Rows, t.history.RowInfos = nil, nil
+			t.history.Hide()
+			t.content.Show()
+		}
+		return
+	}
+
+	t.history.Rows, t.history.RowInfos = t.historyRows()
+	t.content.Hide()
+	t.history.Show()
+	t.history.Refresh()
+}
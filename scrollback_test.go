@@ -0,0 +1,148 @@
+package terminal
+
+import (
+	"strconv"
+	"testing"
+
+	"fyne.io/fyne/v2/widget"
+	"github.com/stretchr/testify/assert"
+	widget2 "github.com/wangyiyang/Magic-Terminal/internal/widget"
+)
+
+func textRow(s string) widget.TextGridRow {
+	row := widget.TextGridRow{}
+	for _, r := range s {
+		row.Cells = append(row.Cells, widget.TextGridCell{Rune: r})
+	}
+	return row
+}
+
+func rowText(row widget.TextGridRow) string {
+	var runes []rune
+	for _, c := range row.Cells {
+		runes = append(runes, c.Rune)
+	}
+	return string(runes)
+}
+
+func TestScrollback_Push(t *testing.T) {
+	s := newScrollback(3)
+	assert.Equal(t, 0, s.len())
+
+	for i := 1; i <= 5; i++ {
+		s.push(textRow(strconv.Itoa(i)), widget2.RowInfo{Wrapped: i == 4})
+	}
+	assert.Equal(t, 3, s.len())
+	assert.Equal(t, "3", rowText(s.row(0)))
+	assert.True(t, s.info(1).Wrapped)
+	assert.False(t, s.info(2).Wrapped)
+	assert.Equal(t, "4", rowText(s.row(1)))
+	assert.Equal(t, "5", rowText(s.row(2)))
+	assert.Equal(t, "", rowText(s.row(3)))
+
+	s.clear()
+	assert.Equal(t, 0, s.len())
+}
+
+func TestScrollback_Resize(t *testing.T) {
+	s := newScrollback(4)
+	for i := 1; i <= 6; i++ {
+		s.push(textRow(strconv.Itoa(i)), widget2.RowInfo{Wrapped: i == 5})
+	}
+
+	s.resize(2)
+	assert.Equal(t, 2, s.len())
+	assert.Equal(t, "5", rowText(s.row(0)))
+	assert.Equal(t, "6", rowText(s.row(1)))
+	assert.True(t, s.info(0).Wrapped)
+
+	s.resize(5)
+	s.push(textRow("7"), widget2.RowInfo{})
+	assert.Equal(t, 3, s.len())
+	assert.Equal(t, "7", rowText(s.row(2)))
+
+	s.resize(0)
+	s.push(textRow("8"), widget2.RowInfo{})
+	assert.Equal(t, 0, s.len())
+}
+
+func TestScrollback_Output(t *testing.T) {
+	term := New()
+	term.config.Columns = 10
+	term.config.Rows = 2
+	term.scrollBottom = 1
+	term.Refresh() // ensure visuals set up
+
+	term.handleOutput([]byte("1\r\n2\r\n3\r\n4"))
+	assert.Equal(t, "3\n4", term.Text())
+	assert.Equal(t, "1\n2\n3\n4", term.TextWithScrollback())
+
+	term.SetScrollbackLines(1)
+	assert.Equal(t, "2\n3\n4", term.TextWithScrollback())
+
+	term.handleOutput([]byte("\x1b[3J"))
+	assert.Equal(t, "3\n4", term.TextWithScrollback())
+}
+
+func TestScrollback_ScrollRegion(t *testing.T) {
+	term := New()
+	term.config.Columns = 10
+	term.config.Rows = 3
+	term.scrollBottom = 2
+	term.Refresh() // ensure visuals set up
+
+	term.handleOutput([]byte("\x1b[1;2r1\r\n2\r\n3\r\n4"))
+	assert.Equal(t, "3\n4", term.Text())
+	assert.Equal(t, 0, term.scrollback.len())
+
+	term.handleOutput([]byte("\x1b[r\x1b[3;1H5\r\n6"))
+	assert.Equal(t, "4\n5\n6", term.Text())
+	assert.Equal(t, "3\n4\n5\n6", term.TextWithScrollback())
+}
+
+func TestScrollback_ScrollHistory(t *testing.T) {
+	term := New()
+	term.config.Columns = 10
+	term.config.Rows = 2
+	term.scrollBottom = 1
+	term.Refresh() // ensure visuals set up
+
+	term.handleOutput([]byte("1\r\n2\r\n3\r\n4"))
+	term.scrollHistory(1)
+	assert.Equal(t, 1, term.scrollOffset)
+	assert.Equal(t, "2\n3", term.history.Text())
+
+	term.scrollHistory(10)
+	assert.Equal(t, 2, term.scrollOffset)
+	assert.Equal(t, "1\n2", term.history.Text())
+
+	term.handleOutput([]byte("\r\n5"))
+	assert.Equal(t, 3, term.scrollOffset)
+	assert.Equal(t, "1\n2", term.history.Text())
+
+	term.TypedRune('a')
+	assert.Equal(t, 0, term.scrollOffset)
+	assert.True(t, term.history.Hidden)
+}
+
+func TestScrollback_SelectHistory(t *testing.T) {
+	term := New()
+	term.config.Columns = 10
+	term.config.Rows = 2
+	term.scrollBottom = 1
+	term.Refresh() // ensure visuals set up
+
+	term.handleOutput([]byte("one\r\ntwo\r\nthree\r\nfour"))
+	term.scrollHistory(2)
+	assert.Equal(t, "one\ntwo", term.history.Text())
+
+	term.selStart, term.selEnd = &position{Row: 1, Col: 2}, &position{Row: 2, Col: 2}
+	term.highlightSelectedText()
+	assert.Equal(t, "ne\ntw", term.SelectedText())
+	style, ok := term.history.Rows[0].Cells[1].Style.(*widget2.TermTextGridStyle)
+	assert.True(t, ok && style.Highlighted)
+
+	term.scrollToBottom()
+	assert.False(t, term.hasSelectedText())
+	assert.False(t, style.Highlighted)
+}
@@ -28,7 +28,7 @@ func (t *Terminal) getSelectedRange() (int, int, int, int) {
 			startRow, endRow = endRow, startRow
 		}
 
-		return startRow - 1, startCol - 1, endRow - 1, endCol - 1
+		return t.widenSelection(startRow-1, startCol-1, endRow-1, endCol-1)
 	}
 	// Check if the user has selected in reverse
 	if startRow > endRow || (startRow == endRow && startCol > endCol) {
@@ -37,18 +37,46 @@ func (t *Terminal) getSelectedRange() (int, int, int, int) {
 		startCol, endCol = endCol, startCol
 	}
 
-	return startRow - 1, startCol - 1, endRow - 1, endCol - 1
+	return t.widenSelection(startRow-1, startCol-1, endRow-1, endCol-1)
+}
+
+// widenSelection extends a selection range so that double width characters at either end are included whole.
+func (t *Terminal) widenSelection(startRow, startCol, endRow, endCol int) (int, int, int, int) {
+	if t.content == nil {
+		return startRow, startCol, endRow, endCol
+	}
+
+	grid := t.selectionGrid()
+	if cells := grid.Row(startRow).Cells; startCol > 0 && startCol < len(cells) && widget2.IsContinuation(cells[startCol]) {
+		startCol--
+	}
+	if cells := grid.Row(endRow).Cells; endCol >= 0 && endCol+1 < len(cells) && widget2.IsContinuation(cells[endCol+1]) {
+		endCol++
+	}
+	return startRow, startCol, endRow, endCol
+}
+
+// selectionGrid returns the rows that selection positions refer to, the history while it is in view or else the screen.
+// The history rows share their cells with the scrollback, so a highlight applied here shows in the history grid.
+func (t *Terminal) selectionGrid() *widget2.TermGrid {
+	if t.scrollOffset == 0 {
+		return t.content
+	}
+
+	grid := widget2.NewTermGrid()
+	grid.Rows, grid.RowInfos = t.historyRows()
+	return grid
 }
 
 func (t *Terminal) highlightSelectedText() {
 	sr, sc, er, ec := t.getSelectedRange()
-	widget2.HighlightRange(t.content, t.blockMode, sr, sc, er, ec, highlightBitMask)
+	widget2.HighlightRange(t.selectionGrid(), t.blockMode, sr, sc, er, ec, highlightBitMask)
 	t.Refresh()
 }
 
 func (t *Terminal) clearSelectedText() {
 	sr, sc, er, ec := t.getSelectedRange()
-	widget2.ClearHighlightRange(t.content, t.blockMode, sr, sc, er, ec)
+	widget2.ClearHighlightRange(t.selectionGrid(), t.blockMode, sr, sc, er, ec)
 	t.Refresh()
 	t.blockMode = false
 	t.selecting = false
@@ -59,7 +87,7 @@ func (t *Terminal) clearSelectedText() {
 // SelectedText gets the text that is currently selected.
 func (t *Terminal) SelectedText() string {
 	sr, sc, er, ec := t.getSelectedRange()
-	return widget2.GetTextRange(t.content, t.blockMode, sr, sc, er, ec)
+	return widget2.GetTextRange(t.selectionGrid(), t.blockMode, sr, sc, er, ec)
 }
 
 func (t *Terminal) copySelectedText(clipboard fyne.Clipboard) {
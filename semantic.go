@@ -0,0 +1,286 @@
+package terminal
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+	widget2 "github.com/wangyiyang/Magic-Terminal/internal/widget"
+)
+
+// PromptShortcuts are the keyboard shortcuts for moving between the shell commands found from semantic prompt marks.
+// They are handled by the terminal instead of being sent to the application. A nil shortcut turns the action off.
+type PromptShortcuts struct {
+	// PreviousPrompt calls ScrollToPreviousPrompt.
+	PreviousPrompt fyne.Shortcut
+	// NextPrompt calls ScrollToNextPrompt.
+	NextPrompt fyne.Shortcut
+	// SelectLastOutput calls SelectLastCommandOutput.
+	SelectLastOutput fyne.Shortcut
+}
+
+// DefaultPromptShortcuts returns the shortcuts that a new terminal uses: Ctrl+Shift+Up and Ctrl+Shift+Down
+// move between prompts and Ctrl+Shift+O selects the last output. Cmd is used instead of Ctrl on macOS.
+func DefaultPromptShortcuts() PromptShortcuts {
+	mods := fyne.KeyModifierShift | fyne.KeyModifierShortcutDefault
+	return PromptShortcuts{
+		PreviousPrompt:   &desktop.CustomShortcut{KeyName: fyne.KeyUp, Modifier: mods},
+		NextPrompt:       &desktop.CustomShortcut{KeyName: fyne.KeyDown, Modifier: mods},
+		SelectLastOutput: &desktop.CustomShortcut{KeyName: fyne.KeyO, Modifier: mods},
+	}
+}
+
+// SetPromptShortcuts changes the keyboard shortcuts for moving between shell commands.
+func (t *Terminal) SetPromptShortcuts(shortcuts PromptShortcuts) {
+	t.promptShortcuts = shortcuts
+}
+
+// typePromptShortcut runs the action of a prompt shortcut, returning false if the shortcut is not one of them.
+func (t *Terminal) typePromptShortcut(s fyne.Shortcut) bool {
+	matches := func(bound fyne.Shortcut) bool {
+		return bound != nil && bound.ShortcutName() == s.ShortcutName()
+	}
+
+	switch {
+	case matches(t.promptShortcuts.PreviousPrompt):
+		t.ScrollToPreviousPrompt()
+	case matches(t.promptShortcuts.NextPrompt):
+		t.ScrollToNextPrompt()
+	case matches(t.promptShortcuts.SelectLastOutput):
+		t.SelectLastCommandOutput()
+	default:
+		return false
+	}
+	return true
+}
+
+// Command is a shell command found from the semantic prompt marks (OSC 133) that shell integration scripts print.
+type Command struct {
+	// Prompt, Input and Output are the parts of the text that the prompt, command line and output cover.
+	// Any of them can be empty, for example a command that printed nothing has no Output.
+	Prompt, Input, Output TextRange
+	// ExitCode is the status that the command finished with, if Finished is set.
+	// It is -1 if the shell did not report a valid status.
+	ExitCode int
+	// Finished is set once the shell reports that the command is done.
+	Finished bool
+}
+
+// TextRange is a part of the text in the terminal, including the scrollback history.
+// Rows count from the oldest line of the history, as in TextWithScrollback, and columns from 0.
+// The end column is exclusive.
+type TextRange struct {
+	StartRow, StartCol, EndRow, EndCol int
+}
+
+// Empty returns true if the range contains no cells.
+func (r TextRange) Empty() bool {
+	return r.StartRow == r.EndRow && r.StartCol == r.EndCol
+}
+
+// Commands returns the shell commands that are still in the terminal, oldest first.
+// It only finds commands if the shell is set up to print semantic prompt marks.
+func (t *Terminal) Commands() []Command {
+	rows, infos := t.primaryScreenRows()
+	var commands []Command
+	var marks []*widget2.CommandMark
+	var open *TextRange // the part of a command that the text is in
+	for row, info := range infos {
+		for _, m := range info.Marks {
+			if open != nil {
+				open.end(rows, row, m.Col)
+				open = nil
+			}
+			if m.Zone == widget2.ZoneNone {
+				continue
+			}
+
+			if len(marks) == 0 || marks[len(marks)-1] != m.Command {
+				commands = append(commands, Command{})
+				marks = append(marks, m.Command)
+			}
+			open = commands[len(commands)-1].zone(m.Zone)
+			*open = TextRange{StartRow: row, StartCol: m.Col, EndRow: row, EndCol: m.Col}
+		}
+	}
+	if open != nil { // the command is still running, or waiting for input, up to the cursor
+		row, col := t.cursorRow, t.cursorCol
+		if t.altScreen {
+			row, col = t.savedRow, t.savedCol
+		}
+		open.end(rows, t.scrollback.len()+row, col)
+	}
+
+	for i, m := range marks {
+		commands[i].ExitCode, commands[i].Finished = m.ExitCode, m.Finished
+	}
+	return commands
+}
+
+func (c *Command) zone(z widget2.CommandZone) *TextRange {
+	switch z {
+	case widget2.ZonePrompt:
+		return &c.Prompt
+	case widget2.ZoneInput:
+		return &c.Input
+	default:
+		return &c.Output
+	}
+}
+
+// end finishes the range before the given position, which is never before the start.
+// A range that ends at the start of a row ends with the text of the row above instead,
+// unless that would leave out the blank line the range started on.
+func (r *TextRange) end(rows []widget.TextGridRow, row, col int) {
+	if row < r.StartRow || (row == r.StartRow && col < r.StartCol) {
+		return
+	}
+	r.EndRow, r.EndCol = row, col
+	if col > 0 || row == 0 || row > len(rows) {
+		return
+	}
+
+	above := len(rows[row-1].Cells)
+	if row-1 > r.StartRow || (row-1 == r.StartRow && above > r.StartCol) {
+		r.EndRow, r.EndCol = row-1, above
+	}
+}
+
+// ScrollToPreviousPrompt scrolls back through the history so that the prompt above the top of the view is at the top.
+func (t *Terminal) ScrollToPreviousPrompt() {
+	top := t.scrollback.len() - t.scrollOffset
+	for row := top - 1; row >= 0; row-- {
+		if hasPrompt(t.scrollback.info(row)) {
+			t.scrollHistory(top - row)
+			return
+		}
+	}
+}
+
+// ScrollToNextPrompt scrolls forward through the history to the next prompt below the top of the view.
+// If there is none in the history the view returns to the live screen.
+func (t *Terminal) ScrollToNextPrompt() {
+	top := t.scrollback.len() - t.scrollOffset
+	for row := top + 1; row < t.scrollback.len(); row++ {
+		if hasPrompt(t.scrollback.info(row)) {
+			t.scrollHistory(top - row)
+			return
+		}
+	}
+	t.scrollToBottom()
+}
+
+// hasPrompt returns true if a prompt starts on the row.
+func hasPrompt(info widget2.RowInfo) bool {
+	for _, m := range info.Marks {
+		if m.Zone == widget2.ZonePrompt {
+			return true
+		}
+	}
+	return false
+}
+
+// SelectLastCommandOutput selects the output of the most recent command that printed something.
+// If the output starts in the history the view is scrolled back to show it, and the selection ends at the bottom of the view.
+func (t *Terminal) SelectLastCommandOutput() {
+	if t.altScreen {
+		return
+	}
+	commands := t.Commands()
+	for i := len(commands) - 1; i >= 0; i-- {
+		out := commands[i].Output
+		if out.Empty() {
+			continue
+		}
+
+		if t.hasSelectedText() {
+			t.clearSelectedText()
+		}
+		history := t.scrollback.len()
+		if out.StartRow < history {
+			t.scrollHistory(history - out.StartRow - t.scrollOffset)
+		} else {
+			t.scrollToBottom()
+		}
+
+		top := history - t.scrollOffset
+		if bottom := top + int(t.config.Rows) - 1; out.EndRow > bottom {
+			out.EndRow, out.EndCol = bottom, int(t.config.Columns)
+		}
+		t.selStart = &position{Col: out.StartCol + 1, Row: out.StartRow - top + 1}
+		t.selEnd = &position{Col: out.EndCol, Row: out.EndRow - top + 1}
+		t.highlightSelectedText()
+		return
+	}
+}
+
+// handleSemanticPrompt handles OSC 133, which marks the start of the prompt (A), the command line (B),
+// the output (C) and the end of a command (D, with an optional exit status).
+// Each mark is kept with the row that the cursor is on, so that it scrolls into the history with the text.
+func (t *Terminal) handleSemanticPrompt(code string) {
+	mark, params, _ := strings.Cut(code, ";")
+	switch mark {
+	case "A":
+		t.command = &widget2.CommandMark{ExitCode: -1}
+		t.addPromptMark(widget2.ZonePrompt)
+	case "B":
+		t.addPromptMark(widget2.ZoneInput)
+	case "C":
+		t.addPromptMark(widget2.ZoneOutput)
+	case "D":
+		if t.command != nil {
+			status, _, _ := strings.Cut(params, ";")
+			if n, err := strconv.Atoi(status); err == nil {
+				t.command.ExitCode = n
+			}
+			t.command.Finished = true
+			t.addPromptMark(widget2.ZoneNone)
+		}
+		t.command = nil
+	default:
+		if t.debug {
+			log.Println("Unsupported semantic prompt mark", code)
+		}
+	}
+}
+
+// addPromptMark records that the given part of the current command starts at the cursor.
+func (t *Terminal) addPromptMark(zone widget2.CommandZone) {
+	if t.command == nil {
+		return
+	}
+
+	info := t.content.RowInfo(t.cursorRow)
+	info.Marks = append(info.Marks[:len(info.Marks):len(info.Marks)], widget2.PromptMark{
+		Col: t.cursorCol, Zone: zone, Command: t.command})
+	t.content.SetRowInfo(t.cursorRow, info)
+}
+
+// primaryScreenRows returns the scrollback history followed by the rows of the primary screen,
+// and what is known about each of them.
+func (t *Terminal) primaryScreenRows() ([]widget.TextGridRow, []widget2.RowInfo) {
+	screen, screenInfos := t.primaryRows, t.primaryInfos
+	if !t.altScreen && t.content != nil {
+		screen, screenInfos = t.content.Rows, t.content.RowInfos
+	}
+
+	history := t.scrollback.len()
+	rows := make([]widget.TextGridRow, 0, history+len(screen))
+	infos := make([]widget2.RowInfo, 0, history+len(screen))
+	for i := 0; i < history; i++ {
+		rows = append(rows, t.scrollback.row(i))
+		infos = append(infos, t.scrollback.info(i))
+	}
+	rows = append(rows, screen...)
+	infos = append(infos, screenInfos...)
+	for len(rows) < len(infos) { // marks can be made on a row before anything is written to it
+		rows = append(rows, widget.TextGridRow{})
+	}
+	for len(infos) < len(rows) {
+		infos = append(infos, widget2.RowInfo{})
+	}
+	return rows, infos
+}
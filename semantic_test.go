@@ -0,0 +1,138 @@
+package terminal
+
+import (
+	"bytes"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"github.com/stretchr/testify/assert"
+)
+
+func semanticCommand(prompt, input, output string, status string) string {
+	return "\x1b]133;A\x07" + prompt + "\x1b]133;B\x07" + input + "\r\n\x1b]133;C\x07" + output + "\x1b]133;D;" + status + "\x07"
+}
+
+func TestSemanticPrompt_Commands(t *testing.T) {
+	term := New()
+	term.Resize(fyne.NewSize(200, 100))
+	term.handleOutput([]byte(semanticCommand("$ ", "ls", "a b\r\nc\r\n", "0") +
+		semanticCommand("$ ", "false", "", "1") + "\x1b]133;A\x07$ "))
+
+	commands := term.Commands()
+	if !assert.Len(t, commands, 3) {
+		return
+	}
+	assert.Equal(t, TextRange{0, 0, 0, 2}, commands[0].Prompt)
+	assert.Equal(t, TextRange{0, 2, 0, 4}, commands[0].Input)
+	assert.Equal(t, TextRange{1, 0, 2, 1}, commands[0].Output)
+	assert.True(t, commands[0].Finished)
+	assert.Equal(t, 0, commands[0].ExitCode)
+
+	assert.Equal(t, TextRange{3, 0, 3, 2}, commands[1].Prompt)
+	assert.Equal(t, TextRange{3, 2, 3, 7}, commands[1].Input)
+	assert.True(t, commands[1].Output.Empty())
+	assert.True(t, commands[1].Finished)
+	assert.Equal(t, 1, commands[1].ExitCode)
+
+	assert.Equal(t, TextRange{4, 0, 4, 2}, commands[2].Prompt)
+	assert.True(t, commands[2].Input.Empty())
+	assert.False(t, commands[2].Finished)
+}
+
+func TestSemanticPrompt_ErasedAndBlank(t *testing.T) {
+	term := New()
+	term.Resize(fyne.NewSize(200, 100))
+	term.handleOutput([]byte("\x1b]133;A\x07\x1b[K$ \x1b]133;B\x07echo\r\n\x1b]133;C\x07\r\n\x1b]133;D;bad\x07"))
+
+	commands := term.Commands()
+	if !assert.Len(t, commands, 1) {
+		return
+	}
+	assert.Equal(t, TextRange{0, 0, 0, 2}, commands[0].Prompt)
+	assert.Equal(t, TextRange{0, 2, 0, 6}, commands[0].Input)
+	assert.Equal(t, TextRange{1, 0, 2, 0}, commands[0].Output) // a blank line
+	assert.True(t, commands[0].Finished)
+	assert.Equal(t, -1, commands[0].ExitCode)
+
+	term.handleOutput([]byte("\x1b[2J"))
+	assert.Empty(t, term.Commands())
+}
+
+func TestSemanticPrompt_Scrollback(t *testing.T) {
+	term := New()
+	term.Resize(fyne.NewSize(200, 100))
+	rows := int(term.config.Rows)
+	for i := 0; i < rows; i++ {
+		term.handleOutput([]byte(semanticCommand("$ ", "echo", "out\r\n", "0")))
+	}
+	term.handleOutput([]byte("\x1b]133;A\x07$ "))
+	history := term.scrollback.len()
+	assert.Greater(t, history, 0)
+
+	commands := term.Commands()
+	assert.Len(t, commands, rows+1)
+	assert.Equal(t, 0, commands[0].Prompt.StartRow)
+	assert.Equal(t, 2, commands[1].Prompt.StartRow)
+
+	top := func() int {
+		return term.scrollback.len() - term.scrollOffset
+	}
+	first := top()
+	term.ScrollToPreviousPrompt()
+	previous := top()
+	assert.Less(t, previous, first)
+	assert.GreaterOrEqual(t, previous, first-2)
+	assert.Equal(t, 0, previous%2) // prompts are on every other row
+
+	term.ScrollToPreviousPrompt()
+	assert.Equal(t, previous-2, top())
+	term.ScrollToNextPrompt()
+	assert.Equal(t, previous, top())
+	for i := 0; i < rows; i++ {
+		term.ScrollToNextPrompt()
+	}
+	assert.Equal(t, 0, term.scrollOffset)
+}
+
+func TestSemanticPrompt_SelectLastOutput(t *testing.T) {
+	term := New()
+	term.Resize(fyne.NewSize(200, 100))
+	term.handleOutput([]byte(semanticCommand("$ ", "ls", "a b\r\nc\r\n", "0") +
+		semanticCommand("$ ", "true", "", "0") + "\x1b]133;A\x07$ "))
+
+	term.SelectLastCommandOutput()
+	assert.Equal(t, "a b\nc", term.SelectedText())
+}
+
+func TestSemanticPrompt_SelectOutputInHistory(t *testing.T) {
+	term := New()
+	term.config.Columns = 10
+	term.config.Rows = 3
+	term.scrollBottom = 2
+	term.Refresh() // ensure visuals set up
+
+	term.handleOutput([]byte(semanticCommand("$ ", "seq", "1\r\n2\r\n3\r\n4\r\n", "0") + "\x1b]133;A\x07$ "))
+	term.SelectLastCommandOutput()
+	assert.Equal(t, 2, term.scrollOffset)
+	assert.Equal(t, "1\n2\n3", term.SelectedText())
+}
+
+func TestSemanticPrompt_Shortcuts(t *testing.T) {
+	term := New()
+	term.Resize(fyne.NewSize(200, 100))
+	for i := 0; i < int(term.config.Rows); i++ {
+		term.handleOutput([]byte(semanticCommand("$ ", "echo", "out\r\n", "0")))
+	}
+	inBuffer := bytes.NewBuffer([]byte{})
+	term.in = NopCloser(inBuffer)
+	up := &desktop.CustomShortcut{KeyName: fyne.KeyUp, Modifier: fyne.KeyModifierShift | fyne.KeyModifierShortcutDefault}
+
+	term.TypedShortcut(up)
+	assert.Greater(t, term.scrollOffset, 0)
+	assert.Equal(t, "", inBuffer.String())
+
+	term.SetPromptShortcuts(PromptShortcuts{})
+	term.TypedShortcut(up)
+	assert.NotEqual(t, "", inBuffer.String()) // sent to the application instead
+}
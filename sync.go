@@ -0,0 +1,63 @@
+package terminal
+
+import (
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+)
+
+// syncUpdateTimeout is how long a synchronized update can hold back the display,
+// so that an application that crashes part way through a frame does not freeze the terminal.
+const syncUpdateTimeout = time.Second / 2
+
+// setSyncUpdate starts or finishes a synchronized update (mode 2026), during which the content is not drawn.
+// The whole frame is drawn by the refresh that follows the output that finishes it.
+func (t *Terminal) setSyncUpdate(on bool) {
+	if t.syncTimer != nil {
+		t.syncTimer.Stop()
+		t.syncTimer = nil
+	}
+
+	t.syncUpdate = on
+	if on {
+		t.syncTimer = time.AfterFunc(syncUpdateTimeout, func() {
+			fyne.Do(t.syncUpdateTimedOut)
+		})
+	}
+}
+
+// syncUpdateTimedOut shows the screen as it is if the application did not finish its update in time.
+func (t *Terminal) syncUpdateTimedOut() {
+	if !t.syncUpdate {
+		return
+	}
+
+	t.setSyncUpdate(false)
+	t.Refresh()
+}
+
+// refreshContent redraws the text, unless a synchronized update is holding it back.
+func (t *Terminal) refreshContent() {
+	if t.syncUpdate {
+		return
+	}
+	t.content.Refresh()
+}
+
+// setCell changes a cell of the content. While a synchronized update is in progress the cell is changed
+// without being drawn, so that the frame appears at once when the update is finished.
+func (t *Terminal) setCell(row, col int, cell widget.TextGridCell) {
+	if !t.syncUpdate {
+		t.content.SetCell(row, col, cell)
+		return
+	}
+
+	for len(t.content.Rows) <= row {
+		t.content.Rows = append(t.content.Rows, widget.TextGridRow{})
+	}
+	for len(t.content.Rows[row].Cells) <= col {
+		t.content.Rows[row].Cells = append(t.content.Rows[row].Cells, widget.TextGridCell{})
+	}
+	t.content.Rows[row].Cells[col] = cell
+}
@@ -45,6 +45,14 @@ const (
 type Terminal struct {
 	widget.BaseWidget
 	fyne.ShortcutHandler
+
+	// OnLinkActivated is called with the URI of a hyperlink that the user clicks with Ctrl (Cmd on macOS) held.
+	// If it is not set the link is opened by the app, usually in the default browser.
+	OnLinkActivated func(uri string)
+	// OnNotification is called when a program in the terminal asks for a desktop notification (OSC 9 or 777).
+	// The title may be empty as not all programs send one.
+	OnNotification func(title, body string)
+
 	content      *widget2.TermGrid
 	config       Config
 	listenerLock sync.Mutex
@@ -55,21 +63,41 @@ type Terminal struct {
 	in  io.WriteCloser
 	out io.Reader
 
-	bell, bold, debug, focused bool
-	currentFG, currentBG       color.Color
-	cursorRow, cursorCol       int
-	savedRow, savedCol         int
-	scrollTop, scrollBottom    int
+	bell, debug, focused    bool
+	currentFG, currentBG    color.Color
+	attributes              widget2.TextAttribute
+	underlineStyle          widget2.UnderlineStyle
+	underlineColor          color.Color
+	cursorRow, cursorCol    int
+	savedRow, savedCol      int
+	scrollTop, scrollBottom int
 
-	cursor                   *canvas.Rectangle
-	cursorHidden, bufferMode bool // buffer mode is an xterm extension that impacts control keys
-	cursorMoved              func()
+	cursor                      *canvas.Rectangle
+	cursorHidden, appCursorKeys bool // application cursor key mode (DECCKM) changes the sequences of cursor keys
+	cursorMoved                 func()
+	cursorShape                 int // the cursor style selected by DECSCUSR, 0 for the default bar
 
 	onMouseDown, onMouseUp func(int, fyne.KeyModifier, fyne.Position)
+	mouseTracking          int      // the mouse tracking mode (9, 1000, 1002 or 1003), 0 if not tracking
+	mouseEncoding          int      // the extended mouse encoding (1005, 1006 or 1015), 0 for the legacy bytes
+	lastMouseCell          position // where motion was last reported, so that it is sent once per cell
 	g0Charset              charSet
 	g1Charset              charSet
 	useG1CharSet           bool
 
+	scrollback   *scrollback
+	scrollOffset int // lines of history scrolled back, 0 when showing the live screen
+	history      *widget2.TermGrid
+
+	altScreen    bool
+	primaryRows  []widget.TextGridRow // the primary screen, kept aside while the alternate screen is in use
+	primaryInfos []widget2.RowInfo
+	altRows      []widget.TextGridRow // the alternate screen, kept aside for mode 47 which does not clear it
+	altInfos     []widget2.RowInfo
+
+	kittyStack    []int // kitty keyboard protocol flags, the last entry is in use
+	kittyAltStack []int // the flags of the screen not showing, each screen has its own stack
+
 	selStart, selEnd *position
 	blockMode        bool
 	selecting        bool
@@ -79,11 +107,33 @@ type Terminal struct {
 		shiftPressed bool
 		ctrlPressed  bool
 		altPressed   bool
+		superPressed bool
+		lastDown     fyne.KeyName // the key pressed most recently, so that repeats can be told apart
+		runeSent     bool         // the key was already sent as an escape sequence, so the rune it types is dropped
 	}
 	newLineMode            bool // new line mode or line feed mode
+	wrapDisabled           bool // auto wrap mode (DECAWM) is on unless this is set
+	screenReversed         bool // reverse video for the whole screen (DECSCNM)
+	appKeypad              bool // application keypad mode (DECKPAM) changes the sequences of keypad keys
+	altSendsEscape         bool // Alt is used as Meta, sending an escape before the character
+	modifyOtherKeys        int  // the xterm modifyOtherKeys level, 2 encodes all keys pressed with modifiers
 	bracketedPasteMode     bool
+	focusReporting         bool // send CSI I and CSI O as focus is gained and lost
+	syncUpdate             bool // an application is drawing a frame (mode 2026), refreshes wait until it is done
+	syncTimer              *time.Timer
 	state                  *parseState
 	blinking               bool
+	hyperlink              *widget2.Hyperlink            // the OSC 8 link that printed text is part of
+	hyperlinks             map[string]*widget2.Hyperlink // links with an ID, so that separate runs join up
+	hoveredLink            *widget2.Hyperlink
+	clipboardPolicy        ClipboardPolicy // what OSC 52 can do with the clipboard
+	clipboardLimit         int             // the most bytes that OSC 52 can copy
+	clipboardAsking        bool
+	palette                [256]color.Color     // colours changed by OSC 4, nil entries use the default palette
+	defaultFG, defaultBG   color.Color          // the default colours set by OSC 10 and 11, nil to follow the theme
+	cursorColor            color.Color          // set by OSC 12, nil to use the theme primary colour
+	command                *widget2.CommandMark // the shell command being printed, from the OSC 133 marks
+	promptShortcuts        PromptShortcuts
 	printData              []byte
 	printer                Printer
 	cmd                    *exec.Cmd
@@ -132,6 +182,20 @@ func (t *Terminal) MinSize() fyne.Size {
 
 // MouseDown handles the down action for desktop mouse events.
 func (t *Terminal) MouseDown(ev *desktop.MouseEvent) {
+	if ev.Button == desktop.MouseButtonPrimary && ev.Modifier&fyne.KeyModifierShortcutDefault != 0 {
+		if link := t.linkAt(ev.Position); link != nil {
+			t.activateLink(link.URI)
+			return
+		}
+	}
+	if t.reportingMouse(ev.Modifier) {
+		if btn := mouseButtonNumber(ev.Button); btn > 0 {
+			t.lastMouseCell = t.getTermPosition(ev.Position)
+			t.onMouseDown(btn, ev.Modifier, ev.Position)
+		}
+		return
+	}
+
 	if t.hasSelectedText() {
 		t.copySelectedText(fyne.CurrentApp().Clipboard())
 		t.clearSelectedText()
@@ -139,30 +203,35 @@ func (t *Terminal) MouseDown(ev *desktop.MouseEvent) {
 	if ev.Button == desktop.MouseButtonSecondary {
 		t.pasteText(fyne.CurrentApp().Clipboard())
 	}
+}
 
-	if t.onMouseDown == nil {
+// MouseUp handles the up action for desktop mouse events.
+func (t *Terminal) MouseUp(ev *desktop.MouseEvent) {
+	if !t.reportingMouse(ev.Modifier) {
 		return
 	}
 
-	if ev.Button == desktop.MouseButtonPrimary {
-		t.onMouseDown(1, ev.Modifier, ev.Position)
-	} else if ev.Button == desktop.MouseButtonSecondary {
-		t.onMouseDown(2, ev.Modifier, ev.Position)
+	if btn := mouseButtonNumber(ev.Button); btn > 0 {
+		t.onMouseUp(btn, ev.Modifier, ev.Position)
 	}
 }
 
-// MouseUp handles the up action for desktop mouse events.
-func (t *Terminal) MouseUp(ev *desktop.MouseEvent) {
+// MouseIn is called when the mouse enters the terminal, it is part of desktop.Hoverable.
+func (t *Terminal) MouseIn(*desktop.MouseEvent) {
+}
 
-	if t.onMouseDown == nil {
-		return
+// MouseMoved is called when the mouse moves over the terminal, it is reported if a motion tracking mode is on.
+// Any hyperlink under the mouse is underlined.
+func (t *Terminal) MouseMoved(ev *desktop.MouseEvent) {
+	t.hoverLink(t.linkAt(ev.Position))
+	if t.reportingMouse(ev.Modifier) {
+		t.reportMouseMotion(mouseButtonNumber(ev.Button), ev.Modifier, *t.sanitizePosition(ev.Position))
 	}
+}
 
-	if ev.Button == desktop.MouseButtonPrimary {
-		t.onMouseUp(1, ev.Modifier, ev.Position)
-	} else if ev.Button == desktop.MouseButtonSecondary {
-		t.onMouseUp(2, ev.Modifier, ev.Position)
-	}
+// MouseOut is called when the mouse leaves the terminal, it is part of desktop.Hoverable.
+func (t *Terminal) MouseOut() {
+	t.hoverLink(nil)
 }
 
 // DoubleTapped handles the double tapped event.
@@ -175,11 +244,12 @@ func (t *Terminal) DoubleTapped(pe *fyne.PointEvent) {
 		t.clearSelectedText()
 	}
 
-	if row < 1 || row > len(t.content.Rows) {
+	grid := t.selectionGrid()
+	if row < 1 || row > len(grid.Rows) {
 		return
 	}
 
-	rowContent := t.content.Rows[row-1].Cells
+	rowContent := grid.Rows[row-1].Cells
 
 	if col < 0 || col >= len(rowContent) {
 		return // No valid character under the cursor, do nothing
@@ -248,6 +318,9 @@ func (t *Terminal) Resize(s fyne.Size) {
 	if t.scrollBottom == 0 || t.scrollBottom == oldRows-1 {
 		t.scrollBottom = int(t.config.Rows) - 1
 	}
+	if oldRows > 0 {
+		t.reflow()
+	}
 	t.onConfigure()
 
 	t.updatePTYSize()
@@ -393,7 +466,7 @@ func (t *Terminal) run() {
 			}
 
 			leftOver = t.handleOutput(fullBuf[:num])
-			if len(leftOver) == 0 {
+			if len(leftOver) == 0 && !t.syncUpdate {
 				t.Refresh()
 			}
 		})
@@ -476,8 +549,11 @@ func (t *Terminal) startingDir() string {
 // New sets up a new terminal instance with the bash shell
 func New() *Terminal {
 	t := &Terminal{
-		mouseCursor: desktop.DefaultCursor,
-		in:          discardWriter{},
+		mouseCursor:     desktop.DefaultCursor,
+		in:              discardWriter{},
+		scrollback:      newScrollback(defaultScrollbackLines),
+		clipboardLimit:  defaultClipboardLimit,
+		promptShortcuts: DefaultPromptShortcuts(),
 	}
 	t.ExtendBaseWidget(t)
 
@@ -508,6 +584,10 @@ func (t *Terminal) sanitizePosition(p fyne.Position) *fyne.Position {
 // Dragged is called by fyne when the left mouse is down and moved whilst over the widget.
 func (t *Terminal) Dragged(d *fyne.DragEvent) {
 	pos := t.sanitizePosition(d.Position)
+	if mods := t.keyboardModifiers(); !t.selecting && t.reportingMouse(mods) {
+		t.reportMouseMotion(1, mods, *pos)
+		return
+	}
 	if !t.selecting {
 		if t.keyboardState.altPressed {
 			t.blockMode = true
@@ -518,7 +598,7 @@ func (t *Terminal) Dragged(d *fyne.DragEvent) {
 	}
 	// clear any previous selection
 	sr, sc, er, ec := t.getSelectedRange()
-	widget2.ClearHighlightRange(t.content, t.blockMode, sr, sc, er, ec)
+	widget2.ClearHighlightRange(t.selectionGrid(), t.blockMode, sr, sc, er, ec)
 
 	// make sure that x,y,x1,y1 are always positive
 	t.selecting = true
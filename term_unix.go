@@ -34,7 +34,7 @@ func (t *Terminal) startPTY() (io.WriteCloser, io.Reader, io.Closer, error) {
 
 	_ = os.Chdir(t.startingDir())
 	env := os.Environ()
-	env = append(env, "TERM=xterm-256color")
+	env = append(env, "TERM="+termName)
 	c := exec.Command(shell)
 	c.Env = env
 	t.cmd = c
@@ -0,0 +1,63 @@
+package terminal
+
+import (
+	"encoding/hex"
+	"log"
+	"strings"
+)
+
+// termName is the terminal type that we set in the environment and report to capability queries.
+const termName = "xterm-256color"
+
+// capabilities describes what this terminal supports, using terminfo names and syntax.
+// Applications can query it (XTGETTCAP) to find features that are missing from the installed terminfo entry.
+// Boolean capabilities have an empty value.
+var capabilities = map[string]string{
+	"TN":     termName,
+	"name":   termName,
+	"Co":     "256",
+	"colors": "256",
+	"RGB":    "8",
+	"Tc":     "",
+
+	"setrgbf": "\x1b[38:2:%p1%d:%p2%d:%p3%dm",
+	"setrgbb": "\x1b[48:2:%p1%d:%p2%d:%p3%dm",
+	"Smulx":   "\x1b[4:%p1%dm",
+	"Setulc":  "\x1b[58:2::%p1%{65536}%/%d:%p1%{256}%/%{255}%&%d:%p1%{255}%&%d%;m",
+	"smxx":    "\x1b[9m",
+	"rmxx":    "\x1b[29m",
+
+	"Ss":   "\x1b[%p1%d q",
+	"Se":   "\x1b[0 q",
+	"Sync": "\x1b[?2026%?%p1%{1}%-%tl%eh%;",
+	"BE":   "\x1b[?2004h",
+	"BD":   "\x1b[?2004l",
+	"PS":   "\x1b[200~",
+	"PE":   "\x1b[201~",
+	"fe":   "\x1b[?1004h",
+	"fd":   "\x1b[?1004l",
+
+	"kbs": "\b",
+}
+
+// requestCapabilities answers an XTGETTCAP request for the hex encoded, semicolon separated capability names.
+// Each capability is answered separately, unknown names are reported as invalid.
+func (t *Terminal) requestCapabilities(names string) {
+	for _, code := range strings.Split(names, ";") {
+		name, err := hex.DecodeString(code)
+		value, ok := capabilities[string(name)]
+		if err != nil || !ok {
+			if t.debug {
+				log.Println("Unknown terminal capability", code)
+			}
+			t.reply("%cP0+r%s%c\\", asciiEscape, code, asciiEscape)
+			continue
+		}
+
+		if value == "" {
+			t.reply("%cP1+r%s%c\\", asciiEscape, code, asciiEscape)
+		} else {
+			t.reply("%cP1+r%s=%X%c\\", asciiEscape, code, value, asciiEscape)
+		}
+	}
+}
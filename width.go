@@ -0,0 +1,32 @@
+package terminal
+
+import (
+	"unicode"
+
+	"golang.org/x/text/width"
+)
+
+const zeroWidthJoiner = '\u200d'
+
+// runeWidth returns the number of cells a rune occupies on screen.
+// Runes that combine with the previous character, such as accents and emoji modifiers, return 0.
+func runeWidth(r rune) int {
+	switch {
+	case r < 0x300:
+		return 1 // fast path for ASCII and Latin
+	case r == zeroWidthJoiner || r == '\u200c': // joiner and non-joiner
+		return 0
+	case r >= 0x1160 && r <= 0x11ff: // Hangul medial vowels and final consonants
+		return 0
+	case r >= 0x1f3fb && r <= 0x1f3ff: // emoji skin tone modifiers
+		return 0
+	case unicode.In(r, unicode.Mn, unicode.Me):
+		return 0
+	}
+
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
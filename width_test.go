@@ -0,0 +1,32 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuneWidth(t *testing.T) {
+	tests := map[string]struct {
+		r    rune
+		want int
+	}{
+		"ASCII":            {'a', 1},
+		"Latin":            {'é', 1},
+		"Box drawing":      {'─', 1},
+		"CJK":              {'中', 2},
+		"Hangul":           {'한', 2},
+		"Fullwidth":        {'Ａ', 2},
+		"Emoji":            {'😀', 2},
+		"Combining accent": {'\u0301', 0},
+		"Joiner":           {zeroWidthJoiner, 0},
+		"Skin tone":        {'\U0001F3FD', 0},
+		"Variation":        {'\ufe0f', 0},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, runeWidth(tt.r))
+		})
+	}
+}